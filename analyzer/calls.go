@@ -0,0 +1,256 @@
+/*
+Copyright © 2025 Vicky Chhetri <vickychhetri4@gmail.com>
+
+Method level call extraction: $this->property->method() and
+$this->method() calls inside a method body.
+*/
+
+package analyzer
+
+import "strings"
+
+type MethodCall struct {
+	Property string // loaded object the call goes through, "" for $this->method() and functions
+	Method   string
+	Function bool // plain function call, e.g. a helper
+	Line     int
+}
+
+// Calls returns the calls a method makes through $this, in source order.
+// $this->user_model->find() yields {Property: "user_model", Method: "find"}
+// and $this->_helper() yields {Method: "_helper"}. Calls through a local
+// get_instance() handle ($CI =& get_instance(); $CI->user_model->find())
+// and plain function calls are included too.
+func (m PHPMethod) Calls() []MethodCall {
+	return bodyCalls(m.body, nil)
+}
+
+// bodyCalls extracts the calls of a method or function body. handles are
+// the class properties holding the CI super object ($this->CI =&
+// get_instance()).
+func bodyCalls(body []Token, handles map[string]bool) []MethodCall {
+	var calls []MethodCall
+	locals := localHandles(body)
+
+	for i := 0; i < len(body); i++ {
+		n, self := ciReceiver(body, i, handles, locals)
+		if n == 0 {
+			// function(
+			if body[i].Kind == TokenIdent && i+1 < len(body) && body[i+1].IsOp("(") &&
+				(i == 0 || !(body[i-1].IsOp("->") || body[i-1].IsOp("?->") || body[i-1].IsOp("::") ||
+					body[i-1].IsKeyword("function") || body[i-1].IsKeyword("new"))) {
+				calls = append(calls, MethodCall{Method: body[i].Text, Function: true, Line: body[i].Line})
+			}
+			continue
+		}
+
+		j := i + n
+		if j+2 >= len(body) || !body[j].IsOp("->") || body[j+1].Kind != TokenIdent {
+			continue
+		}
+
+		// $this->method(
+		if body[j+2].IsOp("(") {
+			if self {
+				calls = append(calls, MethodCall{Method: body[j+1].Text, Line: body[j+1].Line})
+			}
+			i = j + 1
+			continue
+		}
+
+		// $this->property->method(
+		if j+4 < len(body) && body[j+2].IsOp("->") &&
+			body[j+3].Kind == TokenIdent && body[j+4].IsOp("(") {
+			calls = append(calls, MethodCall{
+				Property: body[j+1].Text,
+				Method:   body[j+3].Text,
+				Line:     body[j+3].Line,
+			})
+			i = j + 3
+		}
+	}
+
+	return calls
+}
+
+// ciReceiver returns the length of the expression at body[i] that refers
+// to the CI super object: $this, $this->CI (a handle property), $CI (a
+// local handle) or get_instance(). self is true for a bare $this.
+func ciReceiver(body []Token, i int, handles, locals map[string]bool) (n int, self bool) {
+	t := body[i]
+	switch {
+	case t.Is(TokenVariable, "$this"):
+		if i+2 < len(body) && body[i+1].IsOp("->") && body[i+2].Kind == TokenIdent &&
+			handles[body[i+2].Text] && i+3 < len(body) && body[i+3].IsOp("->") {
+			return 3, false
+		}
+		return 1, true
+	case t.Kind == TokenVariable && locals[t.Text]:
+		return 1, false
+	case t.IsKeyword("get_instance") && i+2 < len(body) && body[i+1].IsOp("(") && body[i+2].IsOp(")"):
+		if i > 0 && (body[i-1].IsOp("->") || body[i-1].IsKeyword("function")) {
+			return 0, false
+		}
+		return 3, false
+	}
+	return 0, false
+}
+
+// getInstanceAt reports whether body[i:] is "get_instance()" or
+// "&get_instance()".
+func getInstanceAt(body []Token, i int) bool {
+	if i < len(body) && body[i].IsOp("&") {
+		i++
+	}
+	return i+2 < len(body) && body[i].IsKeyword("get_instance") &&
+		body[i+1].IsOp("(") && body[i+2].IsOp(")")
+}
+
+// localHandles returns the variables assigned get_instance() in a body.
+func localHandles(body []Token) map[string]bool {
+	locals := map[string]bool{}
+	for i := 0; i+1 < len(body); i++ {
+		if body[i].Kind == TokenVariable && body[i].Text != "$this" &&
+			body[i+1].IsOp("=") && getInstanceAt(body, i+2) {
+			locals[body[i].Text] = true
+		}
+	}
+	return locals
+}
+
+// HandleProperties returns the properties the class assigns the CI super
+// object to, e.g. "CI" for $this->CI =& get_instance().
+func (c *PHPClass) HandleProperties() map[string]bool {
+	handles := map[string]bool{}
+	scan := func(body []Token) {
+		for i := 0; i+3 < len(body); i++ {
+			if body[i].Is(TokenVariable, "$this") && body[i+1].IsOp("->") &&
+				body[i+2].Kind == TokenIdent && body[i+3].IsOp("=") && getInstanceAt(body, i+4) {
+				handles[body[i+2].Text] = true
+			}
+		}
+	}
+	for _, m := range c.Methods {
+		scan(m.body)
+	}
+	for _, m := range c.InheritedMethods {
+		scan(m.body)
+	}
+	return handles
+}
+
+// FindMethod looks a method up by name (case-insensitive, like PHP) among
+// the class' own and inherited methods.
+func (c *PHPClass) FindMethod(name string) (PHPMethod, bool) {
+	for _, m := range c.Methods {
+		if strings.EqualFold(m.Name, name) {
+			return m, true
+		}
+	}
+	for _, m := range c.InheritedMethods {
+		if strings.EqualFold(m.Name, name) {
+			return m.PHPMethod, true
+		}
+	}
+	return PHPMethod{}, false
+}
+
+// reachableCalls returns the property and function calls made by a method
+// and by every $this->method() it calls, transitively.
+func (c *PHPClass) reachableCalls(method PHPMethod) []MethodCall {
+	var calls []MethodCall
+	visited := map[string]bool{}
+	handles := c.HandleProperties()
+
+	var walk func(m PHPMethod)
+	walk = func(m PHPMethod) {
+		key := strings.ToLower(m.Name)
+		if visited[key] {
+			return
+		}
+		visited[key] = true
+
+		for _, call := range bodyCalls(m.body, handles) {
+			if call.Property != "" || call.Function {
+				calls = append(calls, call)
+				continue
+			}
+			if next, ok := c.FindMethod(call.Method); ok {
+				walk(next)
+			}
+		}
+	}
+	walk(method)
+
+	return calls
+}
+
+// methodTableRefs returns the tables a method touches, including the ones
+// touched by the $this->method() helpers it calls.
+func (c *PHPClass) methodTableRefs(method PHPMethod, ctx TableContext) []TableRef {
+	var refs []TableRef
+	visited := map[string]bool{}
+	ctx = ctx.ForClass(c)
+
+	var walk func(m PHPMethod)
+	walk = func(m PHPMethod) {
+		key := strings.ToLower(m.Name)
+		if visited[key] {
+			return
+		}
+		visited[key] = true
+
+		refs = append(refs, ctx.ExtractTableRefs(m.body)...)
+		for _, call := range m.Calls() {
+			if call.Property != "" || call.Function {
+				continue
+			}
+			if next, ok := c.FindMethod(call.Method); ok {
+				walk(next)
+			}
+		}
+	}
+	walk(method)
+
+	return uniqueTableRefs(refs)
+}
+
+// ModelLoads returns the models loaded with $this->load->model() in any
+// of the class' own or inherited methods.
+func (c *PHPClass) ModelLoads() []ModelLoad {
+	var loads []ModelLoad
+	for _, m := range c.Methods {
+		loads = append(loads, ExtractModelsFromTokens(m.body)...)
+	}
+	for _, m := range c.InheritedMethods {
+		loads = append(loads, ExtractModelsFromTokens(m.body)...)
+	}
+	return uniqueLoads(loads)
+}
+
+// LibraryLoads returns the libraries loaded with $this->load->library() in
+// any of the class' own or inherited methods.
+func (c *PHPClass) LibraryLoads() []ModelLoad {
+	var loads []ModelLoad
+	for _, m := range c.Methods {
+		loads = append(loads, ExtractLibrariesFromTokens(m.body)...)
+	}
+	for _, m := range c.InheritedMethods {
+		loads = append(loads, ExtractLibrariesFromTokens(m.body)...)
+	}
+	return uniqueLoads(loads)
+}
+
+// TableRefs returns every table reference in any method of the class,
+// with table names resolved through ctx and the class' own properties.
+func (c *PHPClass) TableRefs(ctx TableContext) []TableRef {
+	var refs []TableRef
+	ctx = ctx.ForClass(c)
+	for _, m := range c.Methods {
+		refs = append(refs, ctx.ExtractTableRefs(m.body)...)
+	}
+	for _, m := range c.InheritedMethods {
+		refs = append(refs, ctx.ExtractTableRefs(m.body)...)
+	}
+	return uniqueTableRefs(refs)
+}
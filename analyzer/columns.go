@@ -0,0 +1,699 @@
+/*
+Copyright © 2025 Vicky Chhetri <vickychhetri4@gmail.com>
+
+Column extraction for CI3 models: the table.column pairs a model method
+reads and writes, from Query Builder chains (select, where, set, insert
+and update arrays, ...) and raw SQL.
+*/
+
+package analyzer
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Where in a query a column is used
+const (
+	ClauseSelect  = "select"
+	ClauseWhere   = "where" // where, like, having and *_in conditions
+	ClauseJoin    = "join"  // join conditions
+	ClauseSet     = "set"   // written: set(), insert/update data, INSERT column lists, UPDATE ... SET
+	ClauseGroupBy = "group_by"
+	ClauseOrderBy = "order_by"
+)
+
+type ColumnRef struct {
+	Table      string
+	Column     string
+	Operation  string // operation of the query the column is used in
+	Clause     string
+	Source     string
+	Connection string
+	Line       int
+}
+
+// ColumnMapping is one column used by a model method.
+type ColumnMapping struct {
+	Module      string
+	Model       string
+	ModelMethod string
+	ModelFile   string
+	Table       string
+	Column      string
+	Operation   string
+	Clause      string
+	Source      string
+	Connection  string
+	Line        int
+}
+
+// BuildColumnMapping extracts the columns used by every method of every
+// model class, inherited methods included (they may use $this->table).
+func BuildColumnMapping(idx *CallIndex) []ColumnMapping {
+	var names []string
+	for name := range idx.models.byName {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var rows []ColumnMapping
+	for _, name := range names {
+		for _, entry := range idx.models.byName[name] {
+			ctx := idx.tables.ForClass(entry.class)
+			methods := append([]PHPMethod{}, entry.class.Methods...)
+			for _, m := range entry.class.InheritedMethods {
+				methods = append(methods, m.PHPMethod)
+			}
+			for _, method := range methods {
+				for _, ref := range ctx.ExtractColumnRefs(method.body) {
+					rows = append(rows, ColumnMapping{
+						Module:      entry.module,
+						Model:       entry.class.ClassName,
+						ModelMethod: method.Name,
+						ModelFile:   entry.file,
+						Table:       ref.Table,
+						Column:      ref.Column,
+						Operation:   ref.Operation,
+						Clause:      ref.Clause,
+						Source:      ref.Source,
+						Connection:  ref.Connection,
+						Line:        ref.Line,
+					})
+				}
+			}
+		}
+	}
+
+	return rows
+}
+
+// ExtractColumnRefs finds the columns used in a method body. Query
+// Builder state is carried across statements until get(), insert(),
+// update(), ... runs the query, like CI's $this->db does. Unqualified
+// columns belong to the main table of the query (the table passed to the
+// final call, or the first from()).
+func (ctx TableContext) ExtractColumnRefs(tokens []Token) []ColumnRef {
+	sig := SignificantTokens(tokens)
+	w := &columnWalker{
+		ctx:      ctx.forBody(sig),
+		sig:      sig,
+		builders: map[string]*qbBuilder{},
+		arrays:   map[string][]string{},
+		used:     map[string]bool{},
+	}
+
+	start := 0
+	for i := 0; i <= len(sig); i++ {
+		if i < len(sig) && !sig[i].IsOp(";") && !sig[i].IsOp("{") && !sig[i].IsOp("}") {
+			continue
+		}
+		w.statement(start, i)
+		start = i + 1
+	}
+
+	// chains that never run here (e.g. a helper method adding where()
+	// clauses) are read from their from() table
+	var groups []string
+	for g := range w.builders {
+		groups = append(groups, g)
+	}
+	sort.Strings(groups)
+	for _, g := range groups {
+		w.run(g, "", OpSelect)
+	}
+
+	fallback := w.ctx.Group
+	if len(w.used) == 1 {
+		for g := range w.used {
+			fallback = g
+		}
+	}
+	for i := range w.refs {
+		if w.refs[i].Connection == "" {
+			w.refs[i].Connection = fallback
+		}
+	}
+
+	return uniqueColumnRefs(w.refs)
+}
+
+// qbBuilder is the pending Query Builder state of one connection.
+type qbBuilder struct {
+	tables  []string          // from() and join() tables
+	aliases map[string]string // table alias → table
+	columns []pendingColumn
+}
+
+type pendingColumn struct {
+	qualifier string
+	column    string
+	clause    string
+	line      int
+}
+
+type columnWalker struct {
+	ctx      TableContext
+	sig      []Token
+	builders map[string]*qbBuilder // by connection group
+	arrays   map[string][]string   // keys of local array variables, e.g. $data
+	used     map[string]bool       // connection groups used in the body
+	refs     []ColumnRef
+}
+
+func (w *columnWalker) builder(group string) *qbBuilder {
+	b, ok := w.builders[group]
+	if !ok {
+		b = &qbBuilder{aliases: map[string]string{}}
+		w.builders[group] = b
+	}
+	return b
+}
+
+func (w *columnWalker) statement(from, to int) {
+	sig := w.sig
+	if from >= to {
+		return
+	}
+
+	w.trackArray(from, to)
+
+	// Query Builder chains on database handles
+	conn := ""
+	for i := from; i < to; i++ {
+		g, n, ok := w.ctx.receiverAt(sig, i)
+		if !ok {
+			continue
+		}
+		conn = g
+		w.used[g] = true
+
+		j := i + n
+		for j+2 < to && sig[j].IsOp("->") && sig[j+1].Kind == TokenIdent && sig[j+2].IsOp("(") {
+			w.call(g, strings.ToLower(sig[j+1].Text), splitArgs(sig, j+2), sig[j+1].Line)
+			j = matchingClose(sig, j+2) + 1
+		}
+		i = j - 1
+	}
+
+	// raw SQL
+	for i := from; i < to; i++ {
+		if !sig[i].IsStringLiteral() || (i > 0 && sig[i-1].IsOp(".")) {
+			continue
+		}
+		sql, end, _ := w.ctx.concat(sig, i)
+		if end > to {
+			end = to
+		}
+		raw := isRawSQL(sig, i, sql)
+		i = end - 1
+		if !raw {
+			continue
+		}
+		group := conn
+		if group == "" {
+			group = w.ctx.Group
+		}
+		for _, ref := range sqlColumnRefs(w.ctx.swapPrefix(sql, group), sig[i].Line) {
+			ref.Connection = conn
+			w.refs = append(w.refs, ref)
+		}
+	}
+}
+
+// trackArray records the keys of "$data = array('a' => ...)",
+// "$data['a'] = ..." and "$rows[] = array('a' => ...)" so they can be used
+// when $data is passed to insert() or update().
+func (w *columnWalker) trackArray(from, to int) {
+	sig := w.sig
+	if sig[from].Kind != TokenVariable || from+2 >= to {
+		return
+	}
+	name := sig[from].Text
+
+	switch {
+	case sig[from+1].IsOp("="):
+		if keys, _, ok := w.arrayLiteral(from+2, to); ok {
+			w.arrays[name] = keys
+		}
+	case sig[from+1].IsOp("[") && from+2 < to && sig[from+2].IsOp("]") &&
+		from+3 < to && sig[from+3].IsOp("="):
+		if keys, _, ok := w.arrayLiteral(from+4, to); ok {
+			w.arrays[name] = keys
+		}
+	case sig[from+1].IsOp("[") && from+4 < to && sig[from+2].IsStringLiteral() &&
+		sig[from+3].IsOp("]") && sig[from+4].IsOp("="):
+		w.arrays[name] = append(w.arrays[name], sig[from+2].StringValue())
+	}
+}
+
+// arrayLiteral returns the string keys and string values of the array
+// literal in sig[from:to] (array(...) or [...]). The keys of a list of
+// rows (insert_batch data) are the keys of its first row.
+func (w *columnWalker) arrayLiteral(from, to int) (keys, values []string, ok bool) {
+	sig := w.sig
+	if from >= to {
+		return nil, nil, false
+	}
+	open := from
+	if sig[from].IsKeyword("array") && from+1 < to && sig[from+1].IsOp("(") {
+		open = from + 1
+	} else if !sig[from].IsOp("[") {
+		return nil, nil, false
+	}
+	if matchingClose(sig, open) != to-1 {
+		return nil, nil, false
+	}
+
+	for _, item := range splitArgs(sig, open) {
+		arrow := -1
+		for k := item[0]; k < item[1]; k++ {
+			if sig[k].IsOp("(") || sig[k].IsOp("[") {
+				k = matchingClose(sig, k)
+				continue
+			}
+			if sig[k].IsOp("=>") {
+				arrow = k
+				break
+			}
+		}
+
+		if arrow < 0 {
+			if v, ok := w.stringArg([2]int{item[0], item[1]}); ok {
+				values = append(values, v)
+			} else if rowKeys, _, ok := w.arrayLiteral(item[0], item[1]); ok && keys == nil {
+				keys = rowKeys
+			}
+			continue
+		}
+		if k, ok := w.stringArg([2]int{item[0], arrow}); ok {
+			keys = append(keys, k)
+		}
+	}
+
+	return keys, values, true
+}
+
+// stringArg evaluates an argument that is a (concatenated) string.
+func (w *columnWalker) stringArg(a [2]int) (string, bool) {
+	if a[0] >= a[1] {
+		return "", false
+	}
+	v, end, ok := w.ctx.concat(w.sig, a[0])
+	if !ok || end != a[1] {
+		return "", false
+	}
+	return v, true
+}
+
+// arrayArg returns the keys and values of an array argument, either a
+// literal or a variable tracked by trackArray.
+func (w *columnWalker) arrayArg(a [2]int) (keys, values []string, ok bool) {
+	if a[1]-a[0] == 1 && w.sig[a[0]].Kind == TokenVariable {
+		keys, ok = w.arrays[w.sig[a[0]].Text]
+		return keys, nil, ok
+	}
+	return w.arrayLiteral(a[0], a[1])
+}
+
+// qbWhereMethods take a column (or an array of column => value) first
+var qbWhereMethods = map[string]bool{
+	"where": true, "or_where": true, "where_in": true, "or_where_in": true,
+	"where_not_in": true, "or_where_not_in": true, "like": true, "or_like": true,
+	"not_like": true, "or_not_like": true, "having": true, "or_having": true,
+}
+
+func (w *columnWalker) call(group, method string, args [][2]int, line int) {
+	b := w.builder(group)
+	arg := func(n int) ([2]int, bool) {
+		if n < len(args) {
+			return args[n], true
+		}
+		return [2]int{}, false
+	}
+	add := func(clause string, exprs ...string) {
+		for _, expr := range exprs {
+			for _, col := range sqlExprColumns(expr) {
+				q, c := splitQualifier(col)
+				b.columns = append(b.columns, pendingColumn{qualifier: q, column: c, clause: clause, line: line})
+			}
+		}
+	}
+	// where(array('a' => 1)) and where('a >', 1) use the key / first
+	// argument; where("a = 1 AND b = 2") is a condition
+	conditions := func(n int) {
+		a, ok := arg(n)
+		if !ok {
+			return
+		}
+		if s, ok := w.stringArg(a); ok {
+			add(ClauseWhere, s)
+		} else if keys, _, ok := w.arrayArg(a); ok {
+			add(ClauseWhere, keys...)
+		}
+	}
+	table := func(n int) string {
+		a, ok := arg(n)
+		if !ok {
+			return ""
+		}
+		s, ok := w.stringArg(a)
+		if !ok {
+			return ""
+		}
+		return w.ctx.qbTable(qbTableName(s), group)
+	}
+	data := func(n int) {
+		if a, ok := arg(n); ok {
+			if keys, _, ok := w.arrayArg(a); ok {
+				add(ClauseSet, keys...)
+			}
+		}
+	}
+
+	switch {
+	case method == "select" || strings.HasPrefix(method, "select_"):
+		a, ok := arg(0)
+		if !ok {
+			return
+		}
+		if s, ok := w.stringArg(a); ok {
+			for _, expr := range splitTopLevel(s) {
+				add(ClauseSelect, stripColumnAlias(expr))
+			}
+		} else if _, values, ok := w.arrayArg(a); ok {
+			for _, expr := range values {
+				add(ClauseSelect, stripColumnAlias(expr))
+			}
+		}
+
+	case method == "from" || method == "join":
+		a, ok := arg(0)
+		if !ok {
+			return
+		}
+		s, ok := w.stringArg(a)
+		if !ok {
+			return
+		}
+		for _, part := range splitTopLevel(s) {
+			name := w.ctx.qbTable(qbTableName(part), group)
+			if name == "" {
+				continue
+			}
+			b.tables = append(b.tables, name)
+			if fields := strings.Fields(part); len(fields) > 1 {
+				b.aliases[strings.ToLower(strings.Trim(fields[len(fields)-1], "`"))] = name
+			}
+		}
+		if method == "join" {
+			if cond, ok := arg(1); ok {
+				if s, ok := w.stringArg(cond); ok {
+					add(ClauseJoin, s)
+				}
+			}
+		}
+
+	case qbWhereMethods[method]:
+		conditions(0)
+
+	case method == "group_by" || method == "order_by":
+		clause := ClauseGroupBy
+		if method == "order_by" {
+			clause = ClauseOrderBy
+		}
+		a, ok := arg(0)
+		if !ok {
+			return
+		}
+		if s, ok := w.stringArg(a); ok {
+			add(clause, s)
+		} else if _, values, ok := w.arrayArg(a); ok {
+			add(clause, values...)
+		}
+
+	case method == "set":
+		a, ok := arg(0)
+		if !ok {
+			return
+		}
+		if s, ok := w.stringArg(a); ok {
+			add(ClauseSet, s)
+		} else {
+			data(0)
+		}
+
+	case method == "get" || method == "count_all_results" || method == "get_compiled_select":
+		w.run(group, table(0), OpSelect)
+
+	case method == "get_where":
+		conditions(1)
+		w.run(group, table(0), OpSelect)
+
+	case method == "insert" || method == "insert_batch" || method == "replace":
+		data(1)
+		op := OpInsert
+		if method == "replace" {
+			op = OpReplace
+		}
+		w.run(group, table(0), op)
+
+	case method == "update" || method == "update_batch":
+		data(1)
+		if method == "update_batch" {
+			if a, ok := arg(2); ok {
+				if s, ok := w.stringArg(a); ok {
+					add(ClauseWhere, s)
+				}
+			}
+		} else {
+			conditions(2)
+		}
+		w.run(group, table(0), OpUpdate)
+
+	case method == "delete":
+		conditions(1)
+		w.run(group, table(0), OpDelete)
+
+	case method == "reset_query" || method == "empty_table" || method == "truncate":
+		delete(w.builders, group)
+	}
+}
+
+// run resolves the pending columns of a connection against the tables of
+// the query and resets its Query Builder state.
+func (w *columnWalker) run(group, table, op string) {
+	b := w.builder(group)
+	delete(w.builders, group)
+
+	main := table
+	if main == "" && len(b.tables) > 0 {
+		main = b.tables[0]
+	}
+	if main == "" {
+		return
+	}
+
+	for _, c := range b.columns {
+		t := main
+		if c.qualifier != "" {
+			if aliased, ok := b.aliases[strings.ToLower(c.qualifier)]; ok {
+				t = aliased
+			} else {
+				t = w.ctx.qbTable(c.qualifier, group)
+			}
+		}
+		w.refs = append(w.refs, ColumnRef{
+			Table:      t,
+			Column:     c.column,
+			Operation:  op,
+			Clause:     c.clause,
+			Source:     SourceQueryBuilder,
+			Connection: group,
+			Line:       c.line,
+		})
+	}
+}
+
+// ------------------------------------------------------------
+// SQL EXPRESSIONS
+// ------------------------------------------------------------
+
+var (
+	sqlStringRegex     = regexp.MustCompile(`'(?:[^'\\]|\\.)*'|"(?:[^"\\]|\\.)*"`)
+	sqlIdentRegex      = regexp.MustCompile("`?[A-Za-z_][A-Za-z0-9_]*`?(?:\\.`?(?:[A-Za-z_][A-Za-z0-9_]*|\\*)`?)?")
+	columnAsRegex      = regexp.MustCompile("(?i)^(.*\\S)\\s+as\\s+`?[A-Za-z_][A-Za-z0-9_]*`?$")
+	columnAliasRegex   = regexp.MustCompile("^(.*[A-Za-z0-9_`)\\]])\\s+`?[A-Za-z_][A-Za-z0-9_]*`?$")
+	sqlClauseRegex     = regexp.MustCompile(`(?i)\b(select|from|where|having|group\s+by|order\s+by|limit|on\s+duplicate\s+key\s+update|set|values|on|join|using|union)\b`)
+	sqlTableAliasRegex = regexp.MustCompile("(?i)\\b(?:from|join|update|into)\\s+`?([A-Za-z0-9_]+(?:`?\\.`?[A-Za-z0-9_]+)?)`?(?:\\s+(?:as\\s+)?`?([A-Za-z_][A-Za-z0-9_]*)`?)?")
+	sqlInsertColumns   = regexp.MustCompile("(?is)\\binto\\s+`?[A-Za-z0-9_.`]+`?\\s*\\(([^)]*)\\)")
+)
+
+// words that are never column names in a SQL expression
+var sqlKeywords = map[string]bool{
+	"and": true, "or": true, "not": true, "null": true, "is": true, "in": true, "like": true,
+	"between": true, "as": true, "distinct": true, "case": true, "when": true, "then": true,
+	"else": true, "end": true, "asc": true, "desc": true, "true": true, "false": true,
+	"interval": true, "exists": true, "select": true, "from": true, "where": true, "all": true,
+	"any": true, "some": true, "escape": true, "regexp": true, "rlike": true, "div": true,
+	"mod": true, "xor": true, "binary": true, "collate": true, "on": true, "using": true,
+	"left": true, "right": true, "inner": true, "outer": true, "cross": true, "join": true,
+	"set": true, "values": true, "limit": true, "offset": true, "group": true, "order": true,
+	"by": true, "having": true, "union": true, "into": true, "update": true, "delete": true,
+	"insert": true, "ignore": true, "duplicate": true, "key": true, "current_timestamp": true,
+	"current_date": true, "day": true, "month": true, "year": true, "hour": true, "minute": true,
+	"second": true, "week": true, "unsigned": true, "signed": true, "char": true,
+}
+
+// sqlExprColumns returns the column names (possibly qualified, e.g.
+// "u.email") used in a SQL expression: string literals, function names,
+// keywords and placeholders are skipped.
+func sqlExprColumns(expr string) []string {
+	expr = sqlStringRegex.ReplaceAllString(expr, "''")
+
+	var cols []string
+	for _, m := range sqlIdentRegex.FindAllStringIndex(expr, -1) {
+		word := strings.Trim(expr[m[0]:m[1]], "`")
+		if m[0] > 0 && strings.ContainsAny(expr[m[0]-1:m[0]], ":@$0123456789") {
+			continue // :param, @var, 1e5
+		}
+		rest := strings.TrimLeft(expr[m[1]:], " \t\r\n")
+		if strings.HasPrefix(rest, "(") {
+			continue // function call
+		}
+		if strings.HasSuffix(word, "*") || sqlKeywords[strings.ToLower(word)] {
+			continue
+		}
+		cols = append(cols, word)
+	}
+	return cols
+}
+
+// stripColumnAlias removes the alias of a select expression:
+// "u.email AS mail" and "COUNT(id) total" become "u.email" and
+// "COUNT(id)".
+func stripColumnAlias(expr string) string {
+	expr = strings.TrimSpace(expr)
+	if m := columnAsRegex.FindStringSubmatch(expr); m != nil {
+		return m[1]
+	}
+	if m := columnAliasRegex.FindStringSubmatch(expr); m != nil {
+		last := strings.Fields(m[1])
+		if len(last) > 0 && !sqlKeywords[strings.ToLower(last[len(last)-1])] {
+			return m[1]
+		}
+	}
+	return expr
+}
+
+// splitQualifier splits "u.email" into "u" and "email".
+func splitQualifier(col string) (qualifier, column string) {
+	col = strings.ReplaceAll(col, "`", "")
+	if i := strings.LastIndex(col, "."); i >= 0 {
+		return col[:i], normalizeTable(col[i+1:])
+	}
+	return "", normalizeTable(col)
+}
+
+// sqlColumnRefs extracts the columns of a raw SQL statement: the select
+// list, INSERT column lists, UPDATE ... SET assignments and the columns of
+// WHERE, ON, HAVING, GROUP BY and ORDER BY. Unqualified columns belong to
+// the first table of the statement.
+func sqlColumnRefs(sql string, line int) []ColumnRef {
+	masked := sqlStringRegex.ReplaceAllStringFunc(sql, func(s string) string {
+		return strings.Repeat("'", len(s))
+	})
+
+	op := OpSelect
+	if m := sqlStatementRegex.FindStringSubmatch(masked); m != nil {
+		op = sqlOperation(m[1])
+	}
+
+	aliases := map[string]string{}
+	main := ""
+	for _, m := range sqlTableAliasRegex.FindAllStringSubmatch(masked, -1) {
+		table := normalizeTable(m[1])
+		if main == "" {
+			main = table
+		}
+		aliases[table] = table
+		if alias := strings.ToLower(m[2]); alias != "" && !sqlKeywords[alias] {
+			aliases[alias] = table
+		}
+	}
+	if main == "" {
+		return nil
+	}
+
+	var refs []ColumnRef
+	add := func(clause string, exprs ...string) {
+		for _, expr := range exprs {
+			for _, col := range sqlExprColumns(expr) {
+				q, c := splitQualifier(col)
+				t := main
+				if q != "" {
+					if aliased, ok := aliases[strings.ToLower(q)]; ok {
+						t = aliased
+					} else {
+						t = normalizeTable(q)
+					}
+				}
+				refs = append(refs, ColumnRef{Table: t, Column: c, Operation: op, Clause: clause, Source: SourceRawSQL, Line: line})
+			}
+		}
+	}
+	assignments := func(section string) {
+		for _, part := range splitTopLevel(section) {
+			if eq := strings.Index(part, "="); eq >= 0 {
+				add(ClauseSet, part[:eq])
+			}
+		}
+	}
+
+	if m := sqlInsertColumns.FindStringSubmatch(masked); m != nil {
+		add(ClauseSet, splitTopLevel(m[1])...)
+	}
+
+	clauses := sqlClauseRegex.FindAllStringSubmatchIndex(masked, -1)
+	for n, m := range clauses {
+		end := len(masked)
+		if n+1 < len(clauses) {
+			end = clauses[n+1][0]
+		}
+		section := masked[m[1]:end]
+
+		switch kw := strings.Join(strings.Fields(strings.ToLower(masked[m[2]:m[3]])), " "); kw {
+		case "select":
+			for _, expr := range splitTopLevel(section) {
+				add(ClauseSelect, stripColumnAlias(expr))
+			}
+		case "where", "having":
+			add(ClauseWhere, section)
+		case "on":
+			add(ClauseJoin, section)
+		case "group by":
+			add(ClauseGroupBy, section)
+		case "order by":
+			add(ClauseOrderBy, section)
+		case "set", "on duplicate key update":
+			assignments(section)
+		}
+	}
+
+	return refs
+}
+
+func uniqueColumnRefs(refs []ColumnRef) []ColumnRef {
+	seen := map[string]bool{}
+	var result []ColumnRef
+	for _, r := range refs {
+		key := r.Table + "|" + r.Column + "|" + r.Operation + "|" + r.Clause + "|" + r.Source + "|" + r.Connection
+		if r.Table == "" || r.Column == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		result = append(result, r)
+	}
+	return result
+}
@@ -0,0 +1,117 @@
+package analyzer
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func columnRefsOf(ctx TableContext, code string) []string {
+	var got []string
+	for _, r := range ctx.ExtractColumnRefs(Tokenize("<?php " + code)) {
+		got = append(got, r.Operation+" "+r.Table+"."+r.Column+" "+r.Clause+" "+r.Source)
+	}
+	sort.Strings(got)
+	return got
+}
+
+func TestExtractColumnRefs(t *testing.T) {
+	prefixed := TableContext{
+		DB: &DatabaseConfig{ActiveGroup: "default", Groups: []DBGroup{
+			{Name: "default", Prefix: "ci_", SwapPre: "{PRE}"},
+		}},
+		Group: "default",
+	}
+
+	tests := []struct {
+		name string
+		ctx  TableContext
+		code string
+		want []string
+	}{
+		{
+			name: "select chain",
+			code: `return $this->db->select('id, email AS mail')->where('status', 1)->order_by('created_at', 'DESC')->get('users')->result();`,
+			want: []string{
+				"SELECT users.created_at order_by query_builder",
+				"SELECT users.email select query_builder",
+				"SELECT users.id select query_builder",
+				"SELECT users.status where query_builder",
+			},
+		},
+		{
+			name: "chain across statements",
+			code: `$this->db->where('id', $id); $this->db->update('users', array('name' => $name));`,
+			want: []string{
+				"UPDATE users.id where query_builder",
+				"UPDATE users.name set query_builder",
+			},
+		},
+		{
+			name: "insert of a data array",
+			code: `$data = array('email' => $email); $data['name'] = $name; $this->db->insert('users', $data);`,
+			want: []string{
+				"INSERT users.email set query_builder",
+				"INSERT users.name set query_builder",
+			},
+		},
+		{
+			name: "join with aliases",
+			code: `$this->db->from('orders o')->join('users u', 'u.id = o.user_id')->get();`,
+			want: []string{
+				"SELECT orders.user_id join query_builder",
+				"SELECT users.id join query_builder",
+			},
+		},
+		{
+			name: "dbprefix on query builder tables",
+			ctx:  prefixed,
+			code: `$this->db->where('email', $email)->get('users');`,
+			want: []string{"SELECT ci_users.email where query_builder"},
+		},
+		{
+			name: "raw select",
+			code: `$this->db->query("SELECT u.email FROM users u WHERE u.status = ? ORDER BY u.name");`,
+			want: []string{
+				"SELECT users.email select raw_sql",
+				"SELECT users.name order_by raw_sql",
+				"SELECT users.status where raw_sql",
+			},
+		},
+		{
+			name: "raw insert with swap_pre",
+			ctx:  prefixed,
+			code: `$this->db->query('INSERT INTO {PRE}logs (level, msg) VALUES (?, ?)', array(1, $msg));`,
+			want: []string{
+				"INSERT ci_logs.level set raw_sql",
+				"INSERT ci_logs.msg set raw_sql",
+			},
+		},
+		{
+			name: "raw update built before query()",
+			code: `$sql = "UPDATE users SET name = ? WHERE id = ?"; $this->db->query($sql, array($name, $id));`,
+			want: []string{
+				"UPDATE users.id where raw_sql",
+				"UPDATE users.name set raw_sql",
+			},
+		},
+		{
+			name: "query() argument that does not start with a statement",
+			code: `$this->db->query(" /* report */ SELECT total FROM sales WHERE region = ?", array($r));`,
+			want: []string{
+				"SELECT sales.region where raw_sql",
+				"SELECT sales.total select raw_sql",
+			},
+		},
+		{
+			name: "strings that are not SQL",
+			code: `$this->form_validation->set_message('required', 'Please update your profile from the settings');`,
+		},
+	}
+
+	for _, tt := range tests {
+		if got := columnRefsOf(tt.ctx, tt.code); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
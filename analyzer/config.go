@@ -0,0 +1,471 @@
+/*
+Copyright © 2025 Vicky Chhetri <vickychhetri4@gmail.com>
+
+Reader for CodeIgniter config files (config.php, routes.php, database.php).
+Config files are plain PHP assignments such as
+
+	$config['base_url'] = '';
+	$db['default'] = array('hostname' => 'localhost', ...);
+
+so instead of executing PHP we evaluate the literal parts of those
+assignments: strings, numbers, booleans, constants, concatenation and
+arrays. Anything more dynamic (function calls, ...) is left unresolved.
+*/
+
+package analyzer
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// PHPArray is an ordered PHP array with string keys. Integer keys are
+// stored in their decimal form, like PHP does when comparing keys.
+type PHPArray struct {
+	keys   []string
+	values map[string]any
+	next   int64
+}
+
+func NewPHPArray() *PHPArray {
+	return &PHPArray{values: map[string]any{}}
+}
+
+// Set stores a value, keeping the original position of existing keys.
+func (a *PHPArray) Set(key string, value any) {
+	if _, ok := a.values[key]; !ok {
+		a.keys = append(a.keys, key)
+	}
+	a.values[key] = value
+	if n, err := strconv.ParseInt(key, 10, 64); err == nil && n >= a.next {
+		a.next = n + 1
+	}
+}
+
+// Append stores a value under the next integer key ($a[] = ...).
+func (a *PHPArray) Append(value any) {
+	a.Set(strconv.FormatInt(a.next, 10), value)
+}
+
+func (a *PHPArray) Get(key string) (any, bool) {
+	v, ok := a.values[key]
+	return v, ok
+}
+
+// Keys returns the keys in insertion order.
+func (a *PHPArray) Keys() []string {
+	return a.keys
+}
+
+func (a *PHPArray) Len() int {
+	return len(a.keys)
+}
+
+// PHPConfig holds the variables assigned in a config file.
+type PHPConfig struct {
+	Vars map[string]any // variable name (without $) → value
+}
+
+// Get walks nested arrays: cfg.Get("db", "default", "dbprefix").
+func (c *PHPConfig) Get(name string, keys ...string) (any, bool) {
+	v, ok := c.Vars[name]
+	for _, k := range keys {
+		if !ok {
+			return nil, false
+		}
+		arr, isArr := v.(*PHPArray)
+		if !isArr {
+			return nil, false
+		}
+		v, ok = arr.Get(k)
+	}
+	return v, ok
+}
+
+// String returns a scalar config value as a string.
+func (c *PHPConfig) String(name string, keys ...string) string {
+	v, ok := c.Get(name, keys...)
+	if !ok {
+		return ""
+	}
+	return configString(v)
+}
+
+// Bool returns a config value using PHP truthiness.
+func (c *PHPConfig) Bool(name string, keys ...string) bool {
+	v, ok := c.Get(name, keys...)
+	if !ok {
+		return false
+	}
+	return configBool(v)
+}
+
+// configBool converts a config value the way PHP casts it to bool.
+func configBool(v any) bool {
+	switch val := v.(type) {
+	case bool:
+		return val
+	case string:
+		return val != "" && val != "0"
+	case int64:
+		return val != 0
+	case float64:
+		return val != 0
+	case *PHPArray:
+		return val.Len() > 0
+	}
+	return false
+}
+
+// Array returns a config value if it is an array.
+func (c *PHPConfig) Array(name string, keys ...string) *PHPArray {
+	v, _ := c.Get(name, keys...)
+	arr, _ := v.(*PHPArray)
+	return arr
+}
+
+func configString(v any) string {
+	switch val := v.(type) {
+	case string:
+		return val
+	case int64:
+		return strconv.FormatInt(val, 10)
+	case float64:
+		return strconv.FormatFloat(val, 'f', -1, 64)
+	case bool:
+		if val {
+			return "1"
+		}
+		return ""
+	}
+	return ""
+}
+
+// ProjectConstants returns the path constants CodeIgniter defines in
+// index.php, resolved for the given project root.
+func ProjectConstants(projectPath string) map[string]string {
+	if abs, err := filepath.Abs(projectPath); err == nil {
+		projectPath = abs
+	}
+	root := filepath.ToSlash(filepath.Clean(projectPath)) + "/"
+	return map[string]string{
+		"FCPATH":   root,
+		"APPPATH":  root + "application/",
+		"BASEPATH": root + "system/",
+		"VIEWPATH": root + "application/views/",
+		"EXT":      ".php",
+	}
+}
+
+// ParseConfigFile reads and evaluates a PHP config file.
+func ParseConfigFile(path string, consts map[string]string) (*PHPConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseConfigTokens(Tokenize(string(data)), consts), nil
+}
+
+// ParseConfigTokens evaluates every "$var[...] = value;" statement in the
+// token stream, in order. Assignments inside if/else blocks are applied as
+// if every branch ran, so the last one wins.
+func ParseConfigTokens(tokens []Token, consts map[string]string) *PHPConfig {
+	e := &configEval{
+		sig:    SignificantTokens(tokens),
+		consts: consts,
+		cfg:    &PHPConfig{Vars: map[string]any{}},
+	}
+
+	for i := 0; i < len(e.sig); {
+		i = e.statement(i)
+	}
+
+	return e.cfg
+}
+
+type configEval struct {
+	sig    []Token
+	consts map[string]string
+	cfg    *PHPConfig
+}
+
+// statementEnd returns the index of the ";" ending the statement that
+// starts at i, or of the next block brace.
+func (e *configEval) statementEnd(i int) int {
+	for ; i < len(e.sig); i++ {
+		t := e.sig[i]
+		if t.IsOp("(") || t.IsOp("[") {
+			i = matchingClose(e.sig, i)
+			continue
+		}
+		if t.IsOp(";") || t.IsOp("{") || t.IsOp("}") {
+			return i
+		}
+	}
+	return len(e.sig)
+}
+
+// statement evaluates the statement at i and returns where the next one
+// starts.
+func (e *configEval) statement(i int) int {
+	end := e.statementEnd(i)
+	next := end + 1
+
+	if e.sig[i].Kind != TokenVariable {
+		return next
+	}
+	name := strings.TrimPrefix(e.sig[i].Text, "$")
+
+	// $name[key][key] = value;
+	var keys []*string
+	j := i + 1
+	for j < end && e.sig[j].IsOp("[") {
+		closeIdx := matchingClose(e.sig, j)
+		if closeIdx == j+1 {
+			keys = append(keys, nil) // $a[] = ...
+		} else {
+			v, ok := e.expr(j+1, closeIdx)
+			if !ok {
+				return next
+			}
+			k := configString(v)
+			keys = append(keys, &k)
+		}
+		j = closeIdx + 1
+	}
+
+	if j >= end || !(e.sig[j].IsOp("=") || e.sig[j].IsOp(".=")) {
+		return next
+	}
+	concat := e.sig[j].IsOp(".=")
+
+	value, ok := e.expr(j+1, end)
+	if !ok {
+		return next
+	}
+
+	e.assign(name, keys, value, concat)
+	return next
+}
+
+func (e *configEval) assign(name string, keys []*string, value any, concat bool) {
+	if len(keys) == 0 {
+		if concat {
+			value = configString(e.cfg.Vars[name]) + configString(value)
+		}
+		e.cfg.Vars[name] = value
+		return
+	}
+
+	arr, ok := e.cfg.Vars[name].(*PHPArray)
+	if !ok {
+		arr = NewPHPArray()
+		e.cfg.Vars[name] = arr
+	}
+
+	for n, key := range keys {
+		last := n == len(keys)-1
+
+		if last {
+			if key == nil {
+				arr.Append(value)
+				return
+			}
+			if concat {
+				old, _ := arr.Get(*key)
+				value = configString(old) + configString(value)
+			}
+			arr.Set(*key, value)
+			return
+		}
+
+		var child *PHPArray
+		if key == nil {
+			child = NewPHPArray()
+			arr.Append(child)
+		} else {
+			existing, _ := arr.Get(*key)
+			child, ok = existing.(*PHPArray)
+			if !ok {
+				child = NewPHPArray()
+				arr.Set(*key, child)
+			}
+		}
+		arr = child
+	}
+}
+
+// expr evaluates sig[from:to]. ok is false if any part of the expression
+// cannot be resolved statically.
+func (e *configEval) expr(from, to int) (any, bool) {
+	if from >= to {
+		return nil, false
+	}
+
+	// concatenation: split on top-level "."
+	var parts []any
+	start := from
+	for i := from; i <= to; i++ {
+		if i < to {
+			t := e.sig[i]
+			if t.IsOp("(") || t.IsOp("[") {
+				i = matchingClose(e.sig, i)
+				continue
+			}
+			if !t.IsOp(".") {
+				continue
+			}
+		}
+		v, ok := e.term(start, i)
+		if !ok {
+			return nil, false
+		}
+		parts = append(parts, v)
+		start = i + 1
+	}
+
+	if len(parts) == 1 {
+		return parts[0], true
+	}
+	var b strings.Builder
+	for _, p := range parts {
+		b.WriteString(configString(p))
+	}
+	return b.String(), true
+}
+
+func (e *configEval) term(from, to int) (any, bool) {
+	if from >= to {
+		return nil, false
+	}
+	t := e.sig[from]
+
+	switch {
+	case to-from == 1 && t.IsStringLiteral():
+		return t.StringValue(), true
+
+	case to-from == 1 && t.Kind == TokenNumber:
+		if n, err := strconv.ParseInt(t.Text, 0, 64); err == nil {
+			return n, true
+		}
+		if f, err := strconv.ParseFloat(t.Text, 64); err == nil {
+			return f, true
+		}
+		return nil, false
+
+	case to-from == 2 && t.IsOp("-") && e.sig[from+1].Kind == TokenNumber:
+		v, ok := e.term(from+1, to)
+		switch n := v.(type) {
+		case int64:
+			return -n, ok
+		case float64:
+			return -n, ok
+		}
+		return nil, false
+
+	case to-from == 1 && t.Kind == TokenIdent:
+		switch strings.ToLower(t.Text) {
+		case "true":
+			return true, true
+		case "false":
+			return false, true
+		case "null":
+			return nil, true
+		}
+		if v, ok := e.consts[t.Text]; ok {
+			return v, true
+		}
+		return nil, false
+
+	case t.Kind == TokenVariable:
+		v, ok := e.cfg.Vars[strings.TrimPrefix(t.Text, "$")]
+		for i := from + 1; ok && i < to; {
+			if !e.sig[i].IsOp("[") {
+				return nil, false
+			}
+			closeIdx := matchingClose(e.sig, i)
+			k, kok := e.expr(i+1, closeIdx)
+			arr, isArr := v.(*PHPArray)
+			if !kok || !isArr {
+				return nil, false
+			}
+			v, ok = arr.Get(configString(k))
+			i = closeIdx + 1
+		}
+		return v, ok
+
+	case t.IsKeyword("array") && from+1 < to && e.sig[from+1].IsOp("("):
+		closeIdx := matchingClose(e.sig, from+1)
+		if closeIdx != to-1 {
+			return nil, false
+		}
+		return e.array(from+2, closeIdx)
+
+	case t.IsOp("["):
+		closeIdx := matchingClose(e.sig, from)
+		if closeIdx != to-1 {
+			return nil, false
+		}
+		return e.array(from+1, closeIdx)
+
+	case t.IsOp("("):
+		closeIdx := matchingClose(e.sig, from)
+		if closeIdx != to-1 {
+			return nil, false
+		}
+		return e.expr(from+1, closeIdx)
+	}
+
+	return nil, false
+}
+
+// array evaluates the items of an array literal, sig[from:to]. Items whose
+// value cannot be resolved are kept with a nil value so the rest of the
+// array (e.g. a database group with a computed db_debug) stays usable.
+func (e *configEval) array(from, to int) (any, bool) {
+	arr := NewPHPArray()
+
+	start := from
+	for i := from; i <= to; i++ {
+		if i < to {
+			t := e.sig[i]
+			if t.IsOp("(") || t.IsOp("[") {
+				i = matchingClose(e.sig, i)
+				continue
+			}
+			if !t.IsOp(",") {
+				continue
+			}
+		}
+		if start < i {
+			e.arrayItem(arr, start, i)
+		}
+		start = i + 1
+	}
+
+	return arr, true
+}
+
+func (e *configEval) arrayItem(arr *PHPArray, from, to int) {
+	for i := from; i < to; i++ {
+		t := e.sig[i]
+		if t.IsOp("(") || t.IsOp("[") {
+			i = matchingClose(e.sig, i)
+			continue
+		}
+		if t.IsOp("=>") {
+			k, ok := e.expr(from, i)
+			if !ok {
+				return
+			}
+			v, _ := e.expr(i+1, to)
+			arr.Set(configString(k), v)
+			return
+		}
+	}
+
+	v, _ := e.expr(from, to)
+	arr.Append(v)
+}
@@ -0,0 +1,268 @@
+/*
+Copyright © 2025 Vicky Chhetri <vickychhetri4@gmail.com>
+
+CRUD matrix: modules × tables with the kind of access each module has.
+*/
+
+package analyzer
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+// crudLetters maps table operations to the CRUD letters shown in the
+// matrix. DDL counts as the closest data operation.
+var crudLetters = map[string]string{
+	OpInsert:   "C",
+	OpReplace:  "C",
+	OpCreate:   "C",
+	OpSelect:   "R",
+	OpUpdate:   "U",
+	OpAlter:    "U",
+	OpDelete:   "D",
+	OpTruncate: "D",
+	OpDrop:     "D",
+}
+
+type CRUDCell struct {
+	Module string
+	Table  string
+	Access string // any of "CRUD", in that order
+}
+
+type CRUDMatrix struct {
+	ReportID     int64
+	Modules      []string
+	Tables       []string
+	Cells        []CRUDCell
+	SharedWrites []string // tables written (C, U or D) by more than one module
+
+	index map[string]string // module + "\x00" + table → access
+}
+
+// BuildCRUDMatrix aggregates mapping rows into a modules × tables matrix.
+// Rows without an operation (older reports) count as reads.
+func BuildCRUDMatrix(reportID int64, mappings []Mapping) *CRUDMatrix {
+	access := map[string]map[string]map[string]bool{}
+	modules := map[string]bool{}
+	tables := map[string]bool{}
+
+	for _, m := range mappings {
+		if m.Table == "" {
+			continue
+		}
+		letter := crudLetters[m.Operation]
+		if letter == "" {
+			letter = "R"
+		}
+
+		modules[m.Module] = true
+		tables[m.Table] = true
+		if access[m.Module] == nil {
+			access[m.Module] = map[string]map[string]bool{}
+		}
+		if access[m.Module][m.Table] == nil {
+			access[m.Module][m.Table] = map[string]bool{}
+		}
+		access[m.Module][m.Table][letter] = true
+	}
+
+	matrix := &CRUDMatrix{
+		ReportID: reportID,
+		Modules:  sortedKeys(modules),
+		Tables:   sortedKeys(tables),
+		index:    map[string]string{},
+	}
+
+	writers := map[string]int{}
+	for _, module := range matrix.Modules {
+		for _, table := range matrix.Tables {
+			letters := access[module][table]
+			if len(letters) == 0 {
+				continue
+			}
+			var b strings.Builder
+			for _, l := range []string{"C", "R", "U", "D"} {
+				if letters[l] {
+					b.WriteString(l)
+				}
+			}
+			if letters["C"] || letters["U"] || letters["D"] {
+				writers[table]++
+			}
+			matrix.Cells = append(matrix.Cells, CRUDCell{Module: module, Table: table, Access: b.String()})
+			matrix.index[module+"\x00"+table] = b.String()
+		}
+	}
+
+	for _, table := range matrix.Tables {
+		if writers[table] > 1 {
+			matrix.SharedWrites = append(matrix.SharedWrites, table)
+		}
+	}
+
+	return matrix
+}
+
+func sortedKeys(set map[string]bool) []string {
+	var keys []string
+	for k := range set {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Access returns the CRUD letters of a module on a table.
+func (m *CRUDMatrix) Access(module, table string) string {
+	return m.index[module+"\x00"+table]
+}
+
+func (m *CRUDMatrix) isSharedWrite(table string) bool {
+	for _, t := range m.SharedWrites {
+		if t == table {
+			return true
+		}
+	}
+	return false
+}
+
+// WriteCSV writes the matrix with one row per table and one column per
+// module.
+func (m *CRUDMatrix) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+
+	header := append([]string{"table"}, m.Modules...)
+	header = append(header, "shared_write")
+	cw.Write(header)
+
+	for _, table := range m.Tables {
+		row := []string{table}
+		for _, module := range m.Modules {
+			row = append(row, m.Access(module, table))
+		}
+		row = append(row, fmt.Sprint(m.isSharedWrite(table)))
+		cw.Write(row)
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+func (m *CRUDMatrix) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(m)
+}
+
+func GenerateCRUDHTMLReport(output string, m *CRUDMatrix) error {
+	var b strings.Builder
+
+	b.WriteString(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="UTF-8">
+<title>CI3 Analyzer – CRUD Matrix</title>
+
+<style>
+body {
+	font-family: Arial, sans-serif;
+	background: #f7f7f7;
+	padding: 20px;
+}
+
+table {
+	border-collapse: collapse;
+	background: #fff;
+	box-shadow: 0 2px 6px rgba(0,0,0,0.1);
+}
+
+th, td {
+	border: 1px solid #ddd;
+	padding: 6px 10px;
+	text-align: center;
+	font-size: 13px;
+}
+
+th {
+	background: #1e1e2f;
+	color: #fff;
+}
+
+td.table-name {
+	text-align: left;
+	font-family: monospace;
+}
+
+td.write {
+	background: #fff3cd;
+	font-weight: bold;
+}
+
+td.read {
+	background: #e7f5ff;
+}
+
+tr.shared td.table-name {
+	background: #f8d7da;
+	font-weight: bold;
+}
+
+.legend span {
+	display: inline-block;
+	padding: 2px 8px;
+	margin-right: 8px;
+	border-radius: 4px;
+}
+</style>
+</head>
+
+<body>
+`)
+
+	fmt.Fprintf(&b, "<h1>CRUD Matrix</h1>\n<p>Map report #%d – %d modules, %d tables, %d tables written by more than one module.</p>\n",
+		m.ReportID, len(m.Modules), len(m.Tables), len(m.SharedWrites))
+
+	b.WriteString(`<p class="legend">` +
+		`<span style="background:#e7f5ff">R = read</span>` +
+		`<span style="background:#fff3cd">C/U/D = write</span>` +
+		`<span style="background:#f8d7da">table written by several modules</span></p>` + "\n")
+
+	b.WriteString("<table>\n<tr><th>Table</th>")
+	for _, module := range m.Modules {
+		fmt.Fprintf(&b, "<th>%s</th>", html.EscapeString(module))
+	}
+	b.WriteString("</tr>\n")
+
+	for _, table := range m.Tables {
+		if m.isSharedWrite(table) {
+			b.WriteString(`<tr class="shared">`)
+		} else {
+			b.WriteString("<tr>")
+		}
+		fmt.Fprintf(&b, `<td class="table-name">%s</td>`, html.EscapeString(table))
+
+		for _, module := range m.Modules {
+			access := m.Access(module, table)
+			class := ""
+			if access == "R" {
+				class = "read"
+			} else if access != "" {
+				class = "write"
+			}
+			fmt.Fprintf(&b, `<td class="%s">%s</td>`, class, access)
+		}
+		b.WriteString("</tr>\n")
+	}
+
+	b.WriteString("</table>\n</body>\n</html>\n")
+
+	return os.WriteFile(output, []byte(b.String()), 0644)
+}
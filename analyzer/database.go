@@ -0,0 +1,131 @@
+/*
+Copyright © 2025 Vicky Chhetri <vickychhetri4@gmail.com>
+
+application/config/database.php: connection groups and which group each
+$this->db / $this->load->database() handle points to.
+*/
+
+package analyzer
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// DBGroup is one $db['name'] connection group. Credentials are not kept.
+type DBGroup struct {
+	Name     string
+	Driver   string
+	Hostname string
+	Database string
+	Prefix   string // dbprefix
+	SwapPre  string
+}
+
+type DatabaseConfig struct {
+	ActiveGroup string
+	Groups      []DBGroup
+}
+
+// ParseDatabaseConfig reads application/config/database.php.
+func ParseDatabaseConfig(projectPath string) (*DatabaseConfig, error) {
+	path := filepath.Join(projectPath, "application", "config", "database.php")
+	cfg, err := ParseConfigFile(path, ProjectConstants(projectPath))
+	if err != nil {
+		return nil, err
+	}
+
+	dbc := &DatabaseConfig{ActiveGroup: cfg.String("active_group")}
+	if dbc.ActiveGroup == "" {
+		dbc.ActiveGroup = "default"
+	}
+
+	if groups := cfg.Array("db"); groups != nil {
+		for _, name := range groups.Keys() {
+			dbc.Groups = append(dbc.Groups, DBGroup{
+				Name:     name,
+				Driver:   cfg.String("db", name, "dbdriver"),
+				Hostname: cfg.String("db", name, "hostname"),
+				Database: cfg.String("db", name, "database"),
+				Prefix:   cfg.String("db", name, "dbprefix"),
+				SwapPre:  cfg.String("db", name, "swap_pre"),
+			})
+		}
+	}
+
+	return dbc, nil
+}
+
+// Group returns a connection group by name.
+func (c *DatabaseConfig) Group(name string) (DBGroup, bool) {
+	if c == nil {
+		return DBGroup{}, false
+	}
+	for _, g := range c.Groups {
+		if g.Name == name {
+			return g, true
+		}
+	}
+	return DBGroup{}, false
+}
+
+// ------------------------------------------------------------
+// $this->load->database() HANDLES
+// ------------------------------------------------------------
+
+// databaseLoads finds the $this->load->database() calls in a body. Loads
+// assigned to something ($this->reporting = ..., $rdb = ...) are returned
+// by target ("reporting", "$rdb"); a plain $this->load->database('x')
+// switches $this->db and is returned as group.
+func databaseLoads(body []Token, active string) (targets map[string]string, group string) {
+	targets = map[string]string{}
+
+	e := &configEval{sig: body, cfg: &PHPConfig{Vars: map[string]any{}}}
+
+	stmt := 0
+	for i := 0; i+4 < len(body); i++ {
+		if body[i].IsOp(";") || body[i].IsOp("{") || body[i].IsOp("}") {
+			stmt = i + 1
+			continue
+		}
+		if !body[i].IsOp("->") || !body[i+1].IsKeyword("load") ||
+			!body[i+2].IsOp("->") || !body[i+3].IsKeyword("database") || !body[i+4].IsOp("(") {
+			continue
+		}
+
+		args := splitArgs(body, i+4)
+		name := active
+		if len(args) > 0 {
+			v, ok := e.expr(args[0][0], args[0][1])
+			switch s, isString := v.(string); {
+			case !ok:
+				name = "?"
+			case isString && s != "" && !strings.Contains(s, "://"):
+				name = s
+			case isString && s != "":
+				name = "?" // DSN string
+			}
+		}
+		returned := len(args) > 1 && args[1][1]-args[1][0] == 1 && body[args[1][0]].IsKeyword("true")
+
+		// $this->reporting = ... / $rdb = ...
+		target := ""
+		s := stmt
+		switch {
+		case s+3 < i && body[s].Is(TokenVariable, "$this") && body[s+1].IsOp("->") &&
+			body[s+2].Kind == TokenIdent && body[s+3].IsOp("="):
+			target = body[s+2].Text
+		case s+1 < i && body[s].Kind == TokenVariable && body[s+1].IsOp("="):
+			target = body[s].Text
+		}
+
+		switch {
+		case target != "":
+			targets[target] = name
+		case !returned:
+			group = name
+		}
+	}
+
+	return targets, group
+}
@@ -2,17 +2,140 @@ package analyzer
 
 import (
 	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
 
 	_ "modernc.org/sqlite"
 )
 
-func OpenDB() (*sql.DB, error) {
-	db, err := sql.Open("sqlite", "ci3-analyzer.db")
+// DBFileName is the name of the SQLite file the analyzer stores its
+// reports in.
+const DBFileName = "ci3-analyzer.db"
+
+// DBPathEnv overrides the default database location.
+const DBPathEnv = "CI3_ANALYZER_DB"
+
+// ProjectStoreDir is the directory of a per-project store, inside the
+// project root.
+const ProjectStoreDir = ".ci3-analyzer"
+
+// ResolveDBPath picks the database file, in order: the --db flag, the
+// CI3_ANALYZER_DB environment variable, the store of the project (when
+// projectStore is set or the project already has one) and finally the
+// per-user data directory. Until the per-user database exists, a
+// ci3-analyzer.db left in the working directory by older versions is
+// used instead.
+func ResolveDBPath(flag, projectPath string, projectStore bool) (string, error) {
+	if flag != "" {
+		return flag, nil
+	}
+	if env := os.Getenv(DBPathEnv); env != "" {
+		return env, nil
+	}
+
+	if projectPath != "" {
+		path := filepath.Join(projectPath, ProjectStoreDir, DBFileName)
+		if projectStore {
+			return path, nil
+		}
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		}
+	} else if projectStore {
+		return "", fmt.Errorf("a project store needs the project path")
+	}
+
+	path, err := DefaultDBPath()
 	if err != nil {
+		return "", err
+	}
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		if _, err := os.Stat(DBFileName); err == nil {
+			return DBFileName, nil
+		}
+	}
+	return path, nil
+}
+
+// DefaultDBPath returns the per-user database.
+func DefaultDBPath() (string, error) {
+	dir, err := userDataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "ci3-analyzer", DBFileName), nil
+}
+
+// userDataDir returns the per-user data directory: $XDG_DATA_HOME or
+// ~/.local/share on Unix, ~/Library/Application Support on macOS and
+// %LocalAppData% on Windows.
+func userDataDir() (string, error) {
+	switch runtime.GOOS {
+	case "windows":
+		if dir := os.Getenv("LocalAppData"); dir != "" {
+			return dir, nil
+		}
+		return os.UserConfigDir()
+	case "darwin", "ios":
+		return os.UserConfigDir() // ~/Library/Application Support
+	}
+
+	if dir := os.Getenv("XDG_DATA_HOME"); dir != "" && filepath.IsAbs(dir) {
+		return dir, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".local", "share"), nil
+}
+
+// OpenDB opens the database at path, creating its directory if needed,
+// and upgrades its schema to the latest version.
+func OpenDB(path string) (*sql.DB, error) {
+	if dir := filepath.Dir(path); dir != "" {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return nil, err
+		}
+	}
+
+	db, err := ConnectDB(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := MigrateDB(db); err != nil {
+		db.Close()
 		return nil, err
 	}
 
-	_, err = db.Exec(`
+	return db, nil
+}
+
+// ConnectDB opens the database at path as it is, without running
+// migrations.
+func ConnectDB(path string) (*sql.DB, error) {
+	return sql.Open("sqlite", path)
+}
+
+// ------------------------------------------------------------
+// SCHEMA MIGRATIONS
+// ------------------------------------------------------------
+
+// DBMigration is one step of the ci3-analyzer.db schema. Databases
+// created before schema_version existed have no version but may have any
+// of the early tables and columns, so the early steps use CREATE TABLE IF
+// NOT EXISTS and addColumn.
+type DBMigration struct {
+	Version int
+	Name    string
+	up      func(tx *sql.Tx) error
+}
+
+var dbMigrations = []DBMigration{
+	{1, "reports and controller → model → table map", execSQL(`
 	CREATE TABLE IF NOT EXISTS reports (
 		id INTEGER PRIMARY KEY AUTOINCREMENT,
 		type TEXT,
@@ -31,7 +154,431 @@ func OpenDB() (*sql.DB, error) {
 		model_file TEXT,
 		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
 	);
-	`)
+	`)},
+
+	{2, "routes", execSQL(`
+	CREATE TABLE IF NOT EXISTS routes (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		report_id INTEGER,
+		pattern TEXT,
+		verb TEXT,
+		target TEXT,
+		module TEXT,
+		controller TEXT,
+		class TEXT,
+		method TEXT,
+		controller_file TEXT,
+		explicit INTEGER,
+		source TEXT,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+	`)},
+
+	{3, "route lineage", execSQL(`
+	CREATE TABLE IF NOT EXISTS route_lineage (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		report_id INTEGER,
+		url TEXT,
+		verb TEXT,
+		explicit INTEGER,
+		module TEXT,
+		controller TEXT,
+		controller_method TEXT,
+		controller_file TEXT,
+		model TEXT,
+		model_method TEXT,
+		model_file TEXT,
+		table_name TEXT,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+	`)},
+
+	{4, "controller and model methods in the map", addColumns(
+		"controller_model_table_map", "controller_method TEXT", "model_method TEXT",
+	)},
+
+	{5, "table operation and source", func(tx *sql.Tx) error {
+		for _, table := range []string{"controller_model_table_map", "route_lineage"} {
+			if err := addColumns(table, "operation TEXT", "source TEXT")(tx); err != nil {
+				return err
+			}
+		}
+		return nil
+	}},
+
+	{6, "unresolved model loads", execSQL(`
+	CREATE TABLE IF NOT EXISTS unresolved_model_loads (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		report_id INTEGER,
+		module TEXT,
+		file TEXT,
+		class TEXT,
+		line INTEGER,
+		path TEXT,
+		alias TEXT,
+		dynamic INTEGER,
+		reason TEXT,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+	`)},
+
+	{7, "libraries and helpers a call goes through (via)", func(tx *sql.Tx) error {
+		for _, table := range []string{"controller_model_table_map", "route_lineage"} {
+			if err := addColumns(table, "via TEXT")(tx); err != nil {
+				return err
+			}
+		}
+		return nil
+	}},
+
+	{8, "database connection groups", func(tx *sql.Tx) error {
+		for _, table := range []string{"controller_model_table_map", "route_lineage"} {
+			if err := addColumns(table, "connection TEXT")(tx); err != nil {
+				return err
+			}
+		}
+		return nil
+	}},
+
+	{9, "schema validation", execSQL(`
+	CREATE TABLE IF NOT EXISTS schema_tables (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		report_id INTEGER,
+		table_name TEXT,
+		source TEXT,
+		referenced INTEGER,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE TABLE IF NOT EXISTS schema_columns (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		report_id INTEGER,
+		table_name TEXT,
+		column_name TEXT,
+		data_type TEXT,
+		nullable INTEGER,
+		primary_key INTEGER
+	);
+
+	CREATE TABLE IF NOT EXISTS unknown_table_refs (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		report_id INTEGER,
+		module TEXT,
+		controller_file TEXT,
+		model TEXT,
+		model_method TEXT,
+		model_file TEXT,
+		table_name TEXT,
+		operation TEXT,
+		source TEXT,
+		connection TEXT,
+		discarded INTEGER,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+	`)},
 
-	return db, err
+	{10, "model method → column map", execSQL(`
+	CREATE TABLE IF NOT EXISTS model_column_map (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		report_id INTEGER,
+		module TEXT,
+		model TEXT,
+		model_method TEXT,
+		model_file TEXT,
+		table_name TEXT,
+		column_name TEXT,
+		operation TEXT,
+		clause TEXT,
+		source TEXT,
+		connection TEXT,
+		line INTEGER
+	);
+	`)},
+
+	{11, "scan results", execSQL(`
+	CREATE TABLE IF NOT EXISTS scan_modules (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		report_id INTEGER,
+		module TEXT,
+		location TEXT,
+		file_count INTEGER
+	);
+
+	CREATE TABLE IF NOT EXISTS scan_files (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		report_id INTEGER,
+		module TEXT,
+		file TEXT,
+		file_path TEXT,
+		folder TEXT,
+		class_count INTEGER,
+		method_count INTEGER,
+		warning_count INTEGER
+	);
+
+	CREATE TABLE IF NOT EXISTS scan_classes (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		report_id INTEGER,
+		module TEXT,
+		file_path TEXT,
+		class_name TEXT,
+		kind TEXT,
+		abstract INTEGER,
+		final INTEGER,
+		extends TEXT,
+		implements TEXT,
+		traits TEXT,
+		ancestors TEXT,
+		start_line INTEGER,
+		end_line INTEGER
+	);
+
+	CREATE TABLE IF NOT EXISTS scan_methods (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		report_id INTEGER,
+		module TEXT,
+		file_path TEXT,
+		class_name TEXT,
+		method_name TEXT,
+		visibility TEXT,
+		static INTEGER,
+		abstract INTEGER,
+		final INTEGER,
+		params TEXT,
+		return_type TEXT,
+		routable INTEGER,
+		start_line INTEGER,
+		end_line INTEGER
+	);
+
+	CREATE TABLE IF NOT EXISTS security_warnings (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		report_id INTEGER,
+		module TEXT,
+		file_path TEXT,
+		line INTEGER,
+		level TEXT,
+		rule TEXT,
+		message TEXT,
+		snippet TEXT
+	);
+	`)},
+
+	{12, "projects", func(tx *sql.Tx) error {
+		if _, err := tx.Exec(`
+		CREATE TABLE IF NOT EXISTS projects (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			root_path TEXT UNIQUE,
+			name TEXT,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		);
+		`); err != nil {
+			return err
+		}
+		if err := addColumns("reports", "project_id INTEGER")(tx); err != nil {
+			return err
+		}
+		return backfillProjects(tx)
+	}},
+
+	{13, "report status", func(tx *sql.Tx) error {
+		if err := addColumns("reports", "status TEXT", "finished_at DATETIME", "error TEXT")(tx); err != nil {
+			return err
+		}
+		// reports written before the status existed are kept as complete
+		_, err := tx.Exec(`UPDATE reports SET status = 'complete' WHERE status IS NULL`)
+		return err
+	}},
+}
+
+// LatestDBVersion is the schema version this build of the analyzer writes.
+func LatestDBVersion() int {
+	return dbMigrations[len(dbMigrations)-1].Version
+}
+
+func execSQL(stmts string) func(tx *sql.Tx) error {
+	return func(tx *sql.Tx) error {
+		_, err := tx.Exec(stmts)
+		return err
+	}
+}
+
+// addColumns adds the columns ("name TYPE") a table is missing. Tables
+// from unversioned databases may already have some of them.
+func addColumns(table string, columns ...string) func(tx *sql.Tx) error {
+	return func(tx *sql.Tx) error {
+		existing, err := tableColumns(tx, table)
+		if err != nil {
+			return err
+		}
+		for _, def := range columns {
+			name := def
+			for i, c := range def {
+				if c == ' ' {
+					name = def[:i]
+					break
+				}
+			}
+			if existing[name] {
+				continue
+			}
+			if _, err := tx.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s", table, def)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+func tableColumns(tx *sql.Tx, table string) (map[string]bool, error) {
+	rows, err := tx.Query(fmt.Sprintf("PRAGMA table_info(%s)", table))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	columns := map[string]bool{}
+	for rows.Next() {
+		var cid, notNull, pk int
+		var name, typ string
+		var dflt sql.NullString
+		if err := rows.Scan(&cid, &name, &typ, &notNull, &dflt, &pk); err != nil {
+			return nil, err
+		}
+		columns[name] = true
+	}
+	return columns, rows.Err()
+}
+
+// DBVersion returns the schema version of a database, 0 for a database
+// created before schema_version existed (or an empty one).
+func DBVersion(db *sql.DB) (int, error) {
+	var n int
+	if err := db.QueryRow(
+		`SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'schema_version'`,
+	).Scan(&n); err != nil || n == 0 {
+		return 0, err
+	}
+
+	var version sql.NullInt64
+	err := db.QueryRow(`SELECT MAX(version) FROM schema_version`).Scan(&version)
+	return int(version.Int64), err
+}
+
+// PendingDBMigrations returns the migrations a database has not run yet.
+func PendingDBMigrations(db *sql.DB) ([]DBMigration, error) {
+	version, err := DBVersion(db)
+	if err != nil {
+		return nil, err
+	}
+	if version > LatestDBVersion() {
+		return nil, fmt.Errorf("database has schema version %d, newer than this ci3-analyzer (%d)", version, LatestDBVersion())
+	}
+
+	var pending []DBMigration
+	for _, m := range dbMigrations {
+		if m.Version > version {
+			pending = append(pending, m)
+		}
+	}
+	return pending, nil
+}
+
+// MigrateDB runs the pending migrations in order, each in its own
+// transaction, and returns the ones it applied.
+func MigrateDB(db *sql.DB) ([]DBMigration, error) {
+	if _, err := db.Exec(`
+	CREATE TABLE IF NOT EXISTS schema_version (
+		version INTEGER PRIMARY KEY,
+		name TEXT,
+		applied_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+	`); err != nil {
+		return nil, err
+	}
+
+	pending, err := PendingDBMigrations(db)
+	if err != nil {
+		return nil, err
+	}
+
+	var applied []DBMigration
+	for _, m := range pending {
+		tx, err := db.Begin()
+		if err != nil {
+			return applied, err
+		}
+		if err := m.up(tx); err != nil {
+			tx.Rollback()
+			return applied, fmt.Errorf("migration %d (%s): %w", m.Version, m.Name, err)
+		}
+		if _, err := tx.Exec(`INSERT INTO schema_version (version, name) VALUES (?, ?)`, m.Version, m.Name); err != nil {
+			tx.Rollback()
+			return applied, err
+		}
+		if err := tx.Commit(); err != nil {
+			return applied, err
+		}
+		applied = append(applied, m)
+	}
+
+	return applied, nil
+}
+
+// AppliedDBMigration is a row of schema_version.
+type AppliedDBMigration struct {
+	Version   int
+	Name      string
+	AppliedAt string
+}
+
+// AppliedDBMigrations lists the migrations recorded in schema_version.
+func AppliedDBMigrations(db *sql.DB) ([]AppliedDBMigration, error) {
+	if version, err := DBVersion(db); err != nil || version == 0 {
+		return nil, err
+	}
+
+	rows, err := db.Query(`SELECT version, name, applied_at FROM schema_version ORDER BY version`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var applied []AppliedDBMigration
+	for rows.Next() {
+		var m AppliedDBMigration
+		if err := rows.Scan(&m.Version, &m.Name, &m.AppliedAt); err != nil {
+			return nil, err
+		}
+		applied = append(applied, m)
+	}
+	return applied, rows.Err()
+}
+
+// DBTableCounts returns the number of rows of every table of a database.
+func DBTableCounts(db *sql.DB) (map[string]int64, error) {
+	rows, err := db.Query(`SELECT name FROM sqlite_master WHERE type = 'table' AND name NOT LIKE 'sqlite_%' ORDER BY name`)
+	if err != nil {
+		return nil, err
+	}
+	var tables []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		tables = append(tables, name)
+	}
+	rows.Close()
+
+	counts := map[string]int64{}
+	for _, t := range tables {
+		var n int64
+		if err := db.QueryRow(fmt.Sprintf(`SELECT COUNT(*) FROM "%s"`, t)).Scan(&n); err != nil {
+			return nil, err
+		}
+		counts[t] = n
+	}
+	return counts, nil
 }
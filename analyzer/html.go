@@ -110,6 +110,46 @@ body {
 	font-family: monospace;
 }
 
+.method-mods {
+	color: #6f42c1;
+}
+
+.method-lines {
+	float: right;
+	color: #6c757d;
+	font-size: 12px;
+}
+
+.docblock {
+	margin: 6px 0 0;
+	color: #6c757d;
+	font-size: 12px;
+	white-space: pre-wrap;
+}
+
+.badge.routable {
+	background: #28a745;
+}
+
+.badge.inherited {
+	background: #6c757d;
+}
+
+#inheritanceBtn {
+	position: fixed;
+	bottom: 70px;
+	right: 20px;
+	background: #6f42c1;
+	color: #fff;
+	border: none;
+	padding: 12px 18px;
+	font-size: 14px;
+	border-radius: 30px;
+	cursor: pointer;
+	box-shadow: 0 4px 10px rgba(0,0,0,0.3);
+	z-index: 9999;
+}
+
 .badge {
 	padding: 2px 6px;
 	font-size: 12px;
@@ -211,6 +251,7 @@ body {
 	</div>
 </div>
 
+<button id="inheritanceBtn">🧬 Controller Inheritance</button>
 <button id="allIssuesBtn">🚨 View All Security Issues</button>
 
 <script>
@@ -244,29 +285,52 @@ const allBtn = document.getElementById("allIssuesBtn");
 		if (module.Files && Array.isArray(module.Files)) {
 			module.Files.forEach(function(file) {
 
-				const className = file.ClassName.toLowerCase();
-				let methodMatched = false;
-
-				if (file.Methods) {
-					methodMatched = file.Methods.some(m =>
-						m.toLowerCase().includes(filter)
-					);
-				}
+				(file.Classes || []).forEach(function(cls) {
+
+					const className = cls.ClassName.toLowerCase();
+					let methodMatched = false;
+
+					if (cls.Methods) {
+						methodMatched = cls.Methods.some(m =>
+							m.Name.toLowerCase().includes(filter)
+						);
+					}
+
+					if (
+						className.includes(filter) ||
+						methodMatched ||
+						moduleMatched
+					) {
+						classMatched = true;
+
+						const classDiv = document.createElement("div");
+						classDiv.className = "class-item";
+						classDiv.textContent = cls.ClassName;
+						if (cls.Kind !== "class") {
+							classDiv.textContent += " (" + cls.Kind + ")";
+						}
+						classDiv.onclick = function () {
+							showClass(module, file, cls);
+						};
+						classList.appendChild(classDiv);
+					}
+				});
 
-				if (
-					className.includes(filter) ||
-					methodMatched ||
+				const functions = file.Functions || [];
+				if (functions.length > 0 && (
+					file.File.toLowerCase().includes(filter) ||
+					functions.some(f => f.Name.toLowerCase().includes(filter)) ||
 					moduleMatched
-				) {
+				)) {
 					classMatched = true;
 
-					const classDiv = document.createElement("div");
-					classDiv.className = "class-item";
-					classDiv.textContent = file.ClassName;
-					classDiv.onclick = function () {
-						showClass(module, file);
+					const fileDiv = document.createElement("div");
+					fileDiv.className = "class-item";
+					fileDiv.textContent = file.File + " (functions)";
+					fileDiv.onclick = function () {
+						showFunctions(module, file);
 					};
-					classList.appendChild(classDiv);
+					classList.appendChild(fileDiv);
 				}
 			});
 		}
@@ -291,18 +355,47 @@ function capitalizeFirst(str) {
   return str.charAt(0).toUpperCase() + str.slice(1);
 }
 
-function showClass(module, file) {
+function showClass(module, file, cls) {
+	let kind = cls.Kind;
+	if (cls.Abstract) kind = "abstract " + kind;
+	if (cls.Final) kind = "final " + kind;
+
 	let html = "<div class='card'>";
 	html += "<h2>" + capitalizeFirst(file.Folder) + "</h2>";
-	html += "<h2>" + file.ClassName + "</h2>";
+	html += "<h2>" + cls.ClassName + " <span class='badge'>" + kind + "</span></h2>";
 	html += "<p><strong>Module:</strong> " + module.Module + "</p>";
-	html += "<p><strong>File:</strong> <code>" + file.File + "</code></p>";
+	if (module.Location) html += "<p><strong>Location:</strong> <code>" + esc(module.Location) + "</code></p>";
+	html += "<p><strong>File:</strong> <code>" + file.File + "</code> (L" + cls.StartLine + "–" + cls.EndLine + ")</p>";
+	if (cls.Ancestors && cls.Ancestors.length > 0) {
+		html += "<p><strong>Inheritance:</strong> " + esc([cls.ClassName].concat(cls.Ancestors).join(" → ")) + "</p>";
+	} else if (cls.Extends) {
+		html += "<p><strong>Extends:</strong> " + esc(cls.Extends) + "</p>";
+	}
+	if (cls.Implements && cls.Implements.length > 0) {
+		html += "<p><strong>" + (cls.Kind === "interface" ? "Extends" : "Implements") + ":</strong> " + esc(cls.Implements.join(", ")) + "</p>";
+	}
+	if (cls.Traits && cls.Traits.length > 0) {
+		html += "<p><strong>Uses traits:</strong> " + esc(cls.Traits.join(", ")) + "</p>";
+	}
+	if ((file.Classes || []).length > 1) {
+		html += "<p><strong>Also declared in this file:</strong> " +
+			file.Classes.filter(c => c !== cls).map(c => esc(c.ClassName)).join(", ") + "</p>";
+	}
 
-	html += "<h3>Methods <span class='badge'>" + file.Methods.length + "</span></h3>";
-	file.Methods.forEach(function(m) {
-		html += "<div class='method'>" + m + "()</div>";
+	const methods = cls.Methods || [];
+	html += "<h3>Methods <span class='badge'>" + methods.length + "</span></h3>";
+	methods.forEach(function(m) {
+		html += renderMethod(m);
 	});
 
+	const inherited = cls.InheritedMethods || [];
+	if (inherited.length > 0) {
+		html += "<h3>Inherited Methods <span class='badge'>" + inherited.length + "</span></h3>";
+		inherited.forEach(function(m) {
+			html += renderMethod(m);
+		});
+	}
+
 	html += "<h3>Security Warnings</h3>";
 
 	if (file.Warnings && file.Warnings.length > 0) {
@@ -317,6 +410,66 @@ function showClass(module, file) {
 	content.innerHTML = html;
 }
 
+function showFunctions(module, file) {
+	let html = "<div class='card'>";
+	html += "<h2>" + capitalizeFirst(file.Folder) + "</h2>";
+	html += "<h2>" + esc(file.File) + "</h2>";
+	html += "<p><strong>Module:</strong> " + module.Module + "</p>";
+
+	html += "<h3>Functions <span class='badge'>" + file.Functions.length + "</span></h3>";
+	file.Functions.forEach(function(f) {
+		html += renderMethod(f);
+	});
+
+	html += "<h3>Security Warnings</h3>";
+
+	if (file.Warnings && file.Warnings.length > 0) {
+		file.Warnings.forEach(function (w) {
+			html += renderWarning(w);
+		});
+	} else {
+		html += "<p style='color:green'>✅ No security issues detected.</p>";
+	}
+
+	html += "</div>";
+	content.innerHTML = html;
+}
+
+function esc(str) {
+	return String(str == null ? "" : str)
+		.replace(/&/g, "&amp;")
+		.replace(/</g, "&lt;")
+		.replace(/>/g, "&gt;");
+}
+
+function renderMethod(m) {
+	const params = (m.Params || []).map(function(p) {
+		let s = "";
+		if (p.Type) s += p.Type + " ";
+		if (p.ByRef) s += "&";
+		if (p.Variadic) s += "...";
+		s += "$" + p.Name;
+		if (p.Default) s += " = " + p.Default;
+		return s;
+	}).join(", ");
+
+	let mods = [m.Visibility];
+	if (m.Abstract) mods.unshift("abstract");
+	if (m.Final) mods.unshift("final");
+	if (m.Static) mods.push("static");
+
+	let html = "<div class='method'>";
+	html += "<span class='method-mods'>" + mods.join(" ") + "</span> ";
+	html += esc(m.Name) + "(" + esc(params) + ")";
+	if (m.ReturnType) html += ": " + esc(m.ReturnType);
+	if (m.Routable) html += "<span class='badge routable'>routable</span>";
+	if (m.From) html += "<span class='badge inherited'>from " + esc(m.From) + "</span>";
+	html += "<span class='method-lines'>L" + m.StartLine + "–" + m.EndLine + "</span>";
+	if (m.DocComment) html += "<pre class='docblock'>" + esc(m.DocComment) + "</pre>";
+	html += "</div>";
+	return html;
+}
+
 function renderWarning(w) {
 	let bg = "#6c757d";
 	if (w.Level === "HIGH") bg = "#dc3545";
@@ -344,7 +497,7 @@ function showAllIssues() {
 			if (!file.Warnings || file.Warnings.length === 0) return;
 
 				found = true;
-				html += "<h3>" + module.Module + " / " + file.ClassName + "</h3>";
+				html += "<h3>" + module.Module + " / " + file.File + "</h3>";
 				file.Warnings.forEach(function(w) {
 					html += renderWarning(w);
 				});
@@ -360,7 +513,34 @@ function showAllIssues() {
 	content.innerHTML = html;
 }
 
+function showInheritance() {
+	const groups = {};
+
+	reports.forEach(function(module) {
+		(module.Files || []).forEach(function(file) {
+			if (!file.IsController) return;
+
+			(file.Classes || []).forEach(function(cls) {
+				if (cls.Kind !== "class" || cls.Abstract) return;
+				const chain = cls.Ancestors && cls.Ancestors.length > 0 ? cls.Ancestors.join(" → ") : "(no parent)";
+				(groups[chain] = groups[chain] || []).push(module.Module + " / " + cls.ClassName);
+			});
+		});
+	});
+
+	let html = "<div class='card'><h1>🧬 Controller Inheritance</h1>";
+	Object.keys(groups).sort().forEach(function(chain) {
+		html += "<h3>" + esc(chain) + " <span class='badge'>" + groups[chain].length + "</span></h3>";
+		groups[chain].sort().forEach(function(name) {
+			html += "<div class='method'>" + esc(name) + "</div>";
+		});
+	});
+	html += "</div>";
+	content.innerHTML = html;
+}
+
 allBtn.onclick = showAllIssues;
+document.getElementById("inheritanceBtn").onclick = showInheritance;
 renderSidebar("");
 
 function createCard(value, label, type, icon) {
@@ -392,8 +572,11 @@ function renderDashboard(reports) {
       fileCount += module.Files.length;
 
       module.Files.forEach(function (file) {
-        if (file.ClassName) classCount++;
-        if (file.Methods) methodCount += file.Methods.length;
+        if (file.Functions) methodCount += file.Functions.length;
+        (file.Classes || []).forEach(function (cls) {
+          classCount++;
+          if (cls.Methods) methodCount += cls.Methods.length;
+        });
         if (file.Warnings) warningCount += file.Warnings.length;
       });
     }
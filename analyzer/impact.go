@@ -0,0 +1,166 @@
+/*
+Copyright © 2025 Vicky Chhetri <vickychhetri4@gmail.com>
+
+Impact analysis: the models, controllers and URLs that depend on a table
+or a column, from the rows stored by the map command.
+*/
+
+package analyzer
+
+import (
+	"sort"
+	"strings"
+)
+
+// ImpactGroup is what depends on the table (or column) within one module
+// for one kind of access.
+type ImpactGroup struct {
+	Module      string
+	Operation   string
+	Models      []string // Model::method
+	Controllers []string // controller files
+	Methods     []string // Controller::method
+	URLs        []string // "GET /users/edit/(:num)"
+}
+
+type Impact struct {
+	ReportID int64
+	Table    string
+	Column   string
+	Tables   []string // physical tables matched, e.g. ci_users for users
+	Groups   []ImpactGroup
+}
+
+// impactSet collects the members of one group without duplicates.
+type impactSet struct {
+	models, controllers, methods, urls map[string]bool
+}
+
+// BuildImpact lists what depends on a table, or on one of its columns
+// when column is set. The table matches with or without one of the
+// dbprefixes of the project.
+func BuildImpact(reportID int64, table, column string, prefixes []string, mappings []Mapping, lineage []LineageRow, columns []ColumnMapping) *Impact {
+	impact := &Impact{ReportID: reportID, Table: table, Column: column}
+
+	want := normalizeTable(table)
+	matched := map[string]bool{}
+	matches := func(t string) bool {
+		t = normalizeTable(t)
+		if t == "" {
+			return false
+		}
+		ok := t == want
+		for _, p := range prefixes {
+			if p != "" && t == strings.ToLower(p)+want {
+				ok = true
+			}
+		}
+		if ok {
+			matched[t] = true
+		}
+		return ok
+	}
+
+	sets := map[string]*impactSet{} // module + "\x00" + operation
+	set := func(module, op string) *impactSet {
+		key := module + "\x00" + op
+		s, ok := sets[key]
+		if !ok {
+			s = &impactSet{models: map[string]bool{}, controllers: map[string]bool{}, methods: map[string]bool{}, urls: map[string]bool{}}
+			sets[key] = s
+		}
+		return s
+	}
+	modelMethod := func(model, method string) string {
+		if method == "" {
+			return model
+		}
+		return model + "::" + method
+	}
+
+	// operations of each model method on the table (or column)
+	ops := map[string]map[string]bool{} // model file + "\x00" + lower(method) → operations
+	methodKey := func(file, method string) string {
+		return file + "\x00" + strings.ToLower(method)
+	}
+	if column != "" {
+		want := normalizeTable(column)
+		for _, c := range columns {
+			if !strings.EqualFold(c.Column, want) || !matches(c.Table) {
+				continue
+			}
+			key := methodKey(c.ModelFile, c.ModelMethod)
+			if ops[key] == nil {
+				ops[key] = map[string]bool{}
+			}
+			ops[key][c.Operation] = true
+			set(c.Module, c.Operation).models[modelMethod(c.Model, c.ModelMethod)] = true
+		}
+	}
+	operations := func(table, op, file, method string) []string {
+		if !matches(table) {
+			return nil
+		}
+		if column == "" {
+			if op == "" {
+				op = OpSelect
+			}
+			return []string{op}
+		}
+		var result []string
+		for o := range ops[methodKey(file, method)] {
+			result = append(result, o)
+		}
+		return result
+	}
+
+	for _, m := range mappings {
+		for _, op := range operations(m.Table, m.Operation, m.ModelFile, m.ModelMethod) {
+			s := set(m.Module, op)
+			s.models[modelMethod(m.Model, m.ModelMethod)] = true
+			// a file-level mapping still names the controller
+			if m.ControllerFile != "" {
+				s.controllers[m.ControllerFile] = true
+			}
+			if m.ControllerMethod == "" {
+				continue
+			}
+			s.methods[strings.TrimSuffix(m.Controller, ".php")+"::"+m.ControllerMethod] = true
+		}
+	}
+
+	for _, r := range lineage {
+		for _, op := range operations(r.Table, r.Operation, r.ModelFile, r.ModelMethod) {
+			verb := r.Verb
+			if verb == "" {
+				verb = "ANY"
+			}
+			set(r.Module, op).urls[verb+" /"+strings.TrimPrefix(r.URL, "/")] = true
+		}
+	}
+
+	for t := range matched {
+		impact.Tables = append(impact.Tables, t)
+	}
+	sort.Strings(impact.Tables)
+
+	var keys []string
+	for k := range sets {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		module, op, _ := strings.Cut(k, "\x00")
+		s := sets[k]
+		impact.Groups = append(impact.Groups, ImpactGroup{
+			Module:      module,
+			Operation:   op,
+			Models:      sortedKeys(s.models),
+			Controllers: sortedKeys(s.controllers),
+			Methods:     sortedKeys(s.methods),
+			URLs:        sortedKeys(s.urls),
+		})
+	}
+
+	return impact
+}
@@ -0,0 +1,42 @@
+package analyzer
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestBuildImpactFileLevelMapping(t *testing.T) {
+	mappings := []Mapping{
+		{
+			Module: "users", Controller: "Users.php", ControllerMethod: "edit",
+			Model: "User_model", ModelMethod: "save", Table: "users", Operation: OpUpdate,
+			ControllerFile: "modules/users/controllers/Users.php", ModelFile: "modules/users/models/User_model.php",
+		},
+		{
+			// the model is loaded but no controller method is known
+			Module: "users", Controller: "Admin.php",
+			Model: "User_model", Table: "users", Operation: OpUpdate,
+			ControllerFile: "modules/users/controllers/Admin.php", ModelFile: "modules/users/models/User_model.php",
+		},
+	}
+
+	impact := BuildImpact(1, "users", "", []string{"ci_"}, mappings, nil, nil)
+
+	if len(impact.Groups) != 1 {
+		t.Fatalf("groups = %+v, want one", impact.Groups)
+	}
+	g := impact.Groups[0]
+	if g.Module != "users" || g.Operation != OpUpdate {
+		t.Errorf("group = %s %s, want users %s", g.Module, g.Operation, OpUpdate)
+	}
+	wantControllers := []string{"modules/users/controllers/Admin.php", "modules/users/controllers/Users.php"}
+	if !reflect.DeepEqual(g.Controllers, wantControllers) {
+		t.Errorf("controllers = %v, want %v", g.Controllers, wantControllers)
+	}
+	if !reflect.DeepEqual(g.Methods, []string{"Users::edit"}) {
+		t.Errorf("methods = %v, want [Users::edit]", g.Methods)
+	}
+	if !reflect.DeepEqual(g.Models, []string{"User_model", "User_model::save"}) {
+		t.Errorf("models = %v, want [User_model User_model::save]", g.Models)
+	}
+}
@@ -0,0 +1,290 @@
+/*
+Copyright © 2025 Vicky Chhetri <vickychhetri4@gmail.com>
+
+Class inheritance resolution for CI3 base classes
+(MY_Controller, Admin_Controller, MY_Model, ...)
+*/
+
+package analyzer
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+type InheritedMethod struct {
+	PHPMethod
+	From string // class or trait that declares the method
+}
+
+type indexedClass struct {
+	class  *PHPClass
+	module string
+}
+
+// ClassIndex looks up class declarations by name across the project.
+type ClassIndex struct {
+	classes map[string][]indexedClass
+}
+
+// BuildClassIndex indexes every class found in the scanned module reports
+// plus the shared base class directories (application/core and
+// application/libraries) that are not part of a module.
+func BuildClassIndex(projectPath string, reports []ModuleReport) *ClassIndex {
+	idx := &ClassIndex{classes: map[string][]indexedClass{}}
+	seen := map[string]bool{}
+
+	for r := range reports {
+		for f := range reports[r].Files {
+			file := &reports[r].Files[f]
+			seen[filepath.Clean(file.FilePathStr)] = true
+			for c := range file.Classes {
+				idx.add(&file.Classes[c], reports[r].Module)
+			}
+		}
+	}
+
+	for _, dir := range []string{"core", "libraries"} {
+		files, _ := ScanPhpFiles(filepath.Join(projectPath, "application", dir))
+		for _, path := range files {
+			if seen[filepath.Clean(path)] {
+				continue
+			}
+			data, err := os.ReadFile(path)
+			if err != nil {
+				continue
+			}
+			parsed := ParsePhpCode(string(data))
+			for c := range parsed.Classes {
+				idx.add(&parsed.Classes[c], "")
+			}
+		}
+	}
+
+	return idx
+}
+
+func classKey(name string) string {
+	if i := strings.LastIndex(name, `\`); i >= 0 {
+		name = name[i+1:]
+	}
+	return strings.ToLower(name)
+}
+
+func (idx *ClassIndex) add(class *PHPClass, module string) {
+	key := classKey(class.ClassName)
+	idx.classes[key] = append(idx.classes[key], indexedClass{class: class, module: module})
+}
+
+// Lookup finds a class by name, preferring a declaration from the given
+// module over shared ones when the name is declared more than once.
+func (idx *ClassIndex) Lookup(name, module string) *PHPClass {
+	candidates := idx.classes[classKey(name)]
+	if len(candidates) == 0 {
+		return nil
+	}
+	for _, c := range candidates {
+		if c.module == module {
+			return c.class
+		}
+	}
+	for _, c := range candidates {
+		if c.module == "" {
+			return c.class
+		}
+	}
+	return candidates[0].class
+}
+
+func (idx *ClassIndex) moduleOf(class *PHPClass) string {
+	for _, c := range idx.classes[classKey(class.ClassName)] {
+		if c.class == class {
+			return c.module
+		}
+	}
+	return ""
+}
+
+// eachAncestor calls fn for the parents of a class, nearest first, with
+// the module each one is declared in. A parent that is not declared in
+// the project is passed as nil and ends the chain, as does a cycle.
+func (idx *ClassIndex) eachAncestor(class *PHPClass, module string, fn func(name string, parent *PHPClass, module string)) {
+	visited := map[string]bool{classKey(class.ClassName): true}
+
+	for class != nil && class.Extends != "" {
+		key := classKey(class.Extends)
+		if visited[key] {
+			return
+		}
+		visited[key] = true
+
+		parent := idx.Lookup(class.Extends, module)
+		if parent != nil {
+			module = idx.moduleOf(parent)
+		}
+		fn(class.Extends, parent, module)
+		class = parent
+	}
+}
+
+// eachInheritedSource calls fn for the classes a class inherits members
+// from, nearest first: its traits, then each declared ancestor followed by
+// the ancestor's traits.
+func (idx *ClassIndex) eachInheritedSource(class *PHPClass, module string, fn func(src *PHPClass)) {
+	traits := func(from *PHPClass, module string) {
+		for _, t := range from.Traits {
+			if trait := idx.Lookup(t, module); trait != nil {
+				fn(trait)
+			}
+		}
+	}
+
+	traits(class, module)
+	idx.eachAncestor(class, module, func(_ string, parent *PHPClass, module string) {
+		if parent != nil {
+			fn(parent)
+			traits(parent, module)
+		}
+	})
+}
+
+// Ancestors returns the parent chain of a class, nearest first. The chain
+// stops at the first parent that is not declared in the project (e.g.
+// CI_Controller or MX_Controller), which is still included by name.
+func (idx *ClassIndex) Ancestors(class *PHPClass, module string) []string {
+	var chain []string
+	idx.eachAncestor(class, module, func(name string, _ *PHPClass, _ string) {
+		chain = append(chain, name)
+	})
+	return chain
+}
+
+// InheritedMethods returns the methods a class gets from its traits and
+// ancestors (and their traits) that it does not override itself.
+func (idx *ClassIndex) InheritedMethods(class *PHPClass, module string) []InheritedMethod {
+	var inherited []InheritedMethod
+
+	defined := map[string]bool{}
+	for _, m := range class.Methods {
+		defined[strings.ToLower(m.Name)] = true
+	}
+
+	idx.eachInheritedSource(class, module, func(src *PHPClass) {
+		for _, m := range src.Methods {
+			key := strings.ToLower(m.Name)
+			if defined[key] || (m.Visibility == "private" && src.Kind != "trait") {
+				continue
+			}
+			defined[key] = true
+			inherited = append(inherited, InheritedMethod{PHPMethod: m, From: src.ClassName})
+		}
+	})
+
+	return inherited
+}
+
+// InheritedProperties returns the properties a class gets from its traits
+// and ancestors that it does not redeclare, nearest declaration first.
+func (idx *ClassIndex) InheritedProperties(class *PHPClass, module string) []PHPProperty {
+	var inherited []PHPProperty
+
+	defined := map[string]bool{}
+	for _, p := range class.Properties {
+		defined[p.Name] = true
+	}
+
+	idx.eachInheritedSource(class, module, func(src *PHPClass) {
+		for _, p := range src.Properties {
+			if defined[p.Name] || (p.Visibility == "private" && src.Kind != "trait") {
+				continue
+			}
+			defined[p.Name] = true
+			inherited = append(inherited, p)
+		}
+	})
+
+	return inherited
+}
+
+// ResolveInheritance fills in Ancestors, InheritedMethods and
+// InheritedProperties for every class in the reports. Inherited public
+// methods of controllers are marked routable, since CodeIgniter dispatches
+// to them just like own methods.
+func ResolveInheritance(projectPath string, reports []ModuleReport) *ClassIndex {
+	idx := BuildClassIndex(projectPath, reports)
+
+	for r := range reports {
+		for f := range reports[r].Files {
+			file := &reports[r].Files[f]
+			controller := reports[r].inDir(file.FilePathStr, "controllers")
+
+			for c := range file.Classes {
+				class := &file.Classes[c]
+				class.Ancestors = idx.Ancestors(class, reports[r].Module)
+				class.InheritedMethods = idx.InheritedMethods(class, reports[r].Module)
+				class.InheritedProperties = idx.InheritedProperties(class, reports[r].Module)
+
+				for i := range class.InheritedMethods {
+					m := &class.InheritedMethods[i]
+					m.Routable = controller && class.Kind == "class" && !class.Abstract && m.IsRoutable()
+				}
+			}
+		}
+	}
+
+	return idx
+}
+
+// BaseClass returns the root of the class' inheritance chain, or "" if the
+// class does not extend anything.
+func (c PHPClass) BaseClass() string {
+	if len(c.Ancestors) == 0 {
+		return c.Extends
+	}
+	return c.Ancestors[len(c.Ancestors)-1]
+}
+
+// DerivesFrom reports whether the class has the named class anywhere in
+// its inheritance chain.
+func (c PHPClass) DerivesFrom(name string) bool {
+	for _, a := range c.Ancestors {
+		if classKey(a) == classKey(name) {
+			return true
+		}
+	}
+	return false
+}
+
+type ControllerBase struct {
+	Module    string
+	File      string
+	ClassName string
+	Ancestors []string
+}
+
+// ControllersNotDerivingFrom lists the concrete controllers whose
+// inheritance chain does not include the given base class (for example an
+// authentication base controller).
+func ControllersNotDerivingFrom(reports []ModuleReport, base string) []ControllerBase {
+	var result []ControllerBase
+	for _, r := range reports {
+		for _, f := range r.Files {
+			if !r.inDir(f.FilePathStr, "controllers") {
+				continue
+			}
+			for _, c := range f.Classes {
+				if c.Kind != "class" || c.Abstract || classKey(c.ClassName) == classKey(base) || c.DerivesFrom(base) {
+					continue
+				}
+				result = append(result, ControllerBase{
+					Module:    r.Module,
+					File:      f.FilePathStr,
+					ClassName: c.ClassName,
+					Ancestors: c.Ancestors,
+				})
+			}
+		}
+	}
+	return result
+}
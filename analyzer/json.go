@@ -0,0 +1,19 @@
+/*
+Copyright © 2025 Vicky Chhetri <vickychhetri4@gmail.com>
+*/
+
+package analyzer
+
+import (
+	"encoding/json"
+	"os"
+)
+
+func GenerateJSONReport(output string, reports []ModuleReport) error {
+	data, err := json.MarshalIndent(reports, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(output, data, 0644)
+}
@@ -0,0 +1,589 @@
+/*
+Copyright © 2025 Vicky Chhetri <vickychhetri4@gmail.com>
+
+A small PHP lexer. It is not a full implementation of the Zend scanner,
+but it understands everything that matters for static analysis of
+CodeIgniter code: inline HTML, comments, docblocks, single/double quoted
+strings, heredoc/nowdoc and brace nesting.
+*/
+
+package analyzer
+
+import (
+	"strings"
+)
+
+type TokenKind int
+
+const (
+	TokenInlineHTML TokenKind = iota
+	TokenOpenTag
+	TokenCloseTag
+	TokenWhitespace
+	TokenComment
+	TokenDocComment
+	TokenVariable // $name
+	TokenIdent    // identifiers and keywords
+	TokenString   // '...' or "..."
+	TokenHeredoc  // <<<EOT ... EOT and <<<'EOT' ... EOT
+	TokenNumber
+	TokenOperator // punctuation and operators: -> :: => ( { ; ...
+)
+
+var tokenKindNames = map[TokenKind]string{
+	TokenInlineHTML: "INLINE_HTML",
+	TokenOpenTag:    "OPEN_TAG",
+	TokenCloseTag:   "CLOSE_TAG",
+	TokenWhitespace: "WHITESPACE",
+	TokenComment:    "COMMENT",
+	TokenDocComment: "DOC_COMMENT",
+	TokenVariable:   "VARIABLE",
+	TokenIdent:      "IDENT",
+	TokenString:     "STRING",
+	TokenHeredoc:    "HEREDOC",
+	TokenNumber:     "NUMBER",
+	TokenOperator:   "OPERATOR",
+}
+
+func (k TokenKind) String() string {
+	return tokenKindNames[k]
+}
+
+type Token struct {
+	Kind  TokenKind
+	Text  string // raw source text of the token
+	Line  int    // line the token starts on (1-based)
+	Depth int    // curly brace nesting depth before the token
+}
+
+// Is reports whether the token has the given kind and (case-insensitive) text.
+func (t Token) Is(kind TokenKind, text string) bool {
+	return t.Kind == kind && strings.EqualFold(t.Text, text)
+}
+
+// IsOp reports whether the token is the given operator.
+func (t Token) IsOp(op string) bool {
+	return t.Kind == TokenOperator && t.Text == op
+}
+
+// IsKeyword reports whether the token is the given identifier/keyword.
+// PHP keywords are case-insensitive.
+func (t Token) IsKeyword(word string) bool {
+	return t.Is(TokenIdent, word)
+}
+
+// IsStringLiteral reports whether the token holds a quoted string or heredoc.
+func (t Token) IsStringLiteral() bool {
+	return t.Kind == TokenString || t.Kind == TokenHeredoc
+}
+
+// EndLine returns the line the token ends on.
+func (t Token) EndLine() int {
+	return t.Line + strings.Count(t.Text, "\n")
+}
+
+// StringValue returns the contents of a string or heredoc token with the
+// quotes / heredoc markers removed and simple escapes resolved. Variables
+// inside double-quoted strings are left untouched.
+func (t Token) StringValue() string {
+	switch t.Kind {
+	case TokenString:
+		if len(t.Text) < 2 {
+			return ""
+		}
+		body := t.Text[1 : len(t.Text)-1]
+		if t.Text[0] == '\'' {
+			return unescapeSingleQuoted(body)
+		}
+		return unescapeDoubleQuoted(body)
+
+	case TokenHeredoc:
+		nl := strings.IndexByte(t.Text, '\n')
+		if nl < 0 {
+			return ""
+		}
+		body := t.Text[nl+1:]
+		// drop the closing marker line
+		if last := strings.LastIndexByte(body, '\n'); last >= 0 {
+			body = body[:last]
+		} else {
+			body = ""
+		}
+		if strings.Contains(t.Text[:nl], "'") {
+			return body
+		}
+		return unescapeDoubleQuoted(body)
+	}
+	return t.Text
+}
+
+func unescapeSingleQuoted(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) && (s[i+1] == '\'' || s[i+1] == '\\') {
+			i++
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+func unescapeDoubleQuoted(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i+1 >= len(s) {
+			b.WriteByte(s[i])
+			continue
+		}
+		i++
+		switch s[i] {
+		case 'n':
+			b.WriteByte('\n')
+		case 't':
+			b.WriteByte('\t')
+		case 'r':
+			b.WriteByte('\r')
+		case '"', '\\', '$':
+			b.WriteByte(s[i])
+		default:
+			b.WriteByte('\\')
+			b.WriteByte(s[i])
+		}
+	}
+	return b.String()
+}
+
+// ------------------------------------------------------------
+// LEXER
+// ------------------------------------------------------------
+
+// multi-character operators, longest first
+var phpOperators = []string{
+	"<<=", ">>=", "**=", "...", "<=>", "===", "!==", "??=", "?->",
+	"->", "=>", "::", "==", "!=", "<>", "<=", ">=", "&&", "||", "??",
+	"++", "--", "+=", "-=", "*=", "/=", ".=", "%=", "&=", "|=", "^=",
+	"<<", ">>", "**", "#[",
+}
+
+type lexer struct {
+	src    string
+	pos    int
+	line   int
+	depth  int
+	tokens []Token
+}
+
+// Tokenize splits PHP source code into tokens. It never fails: anything it
+// does not recognise becomes a single character operator token.
+func Tokenize(code string) []Token {
+	lx := &lexer{src: code, line: 1}
+	lx.run()
+	return lx.tokens
+}
+
+func (lx *lexer) emit(kind TokenKind, start int) {
+	text := lx.src[start:lx.pos]
+	tok := Token{Kind: kind, Text: text, Line: lx.line, Depth: lx.depth}
+	lx.tokens = append(lx.tokens, tok)
+	lx.line += strings.Count(text, "\n")
+
+	if kind == TokenOperator {
+		switch text {
+		case "{":
+			lx.depth++
+		case "}":
+			if lx.depth > 0 {
+				lx.depth--
+			}
+		}
+	}
+}
+
+func (lx *lexer) run() {
+	for lx.pos < len(lx.src) {
+		lx.lexInlineHTML()
+		lx.lexPHP()
+	}
+}
+
+// lexInlineHTML consumes everything up to the next PHP open tag.
+func (lx *lexer) lexInlineHTML() {
+	start := lx.pos
+	idx := strings.Index(lx.src[lx.pos:], "<?")
+	if idx < 0 {
+		lx.pos = len(lx.src)
+		if lx.pos > start {
+			lx.emit(TokenInlineHTML, start)
+		}
+		return
+	}
+
+	lx.pos += idx
+	if lx.pos > start {
+		lx.emit(TokenInlineHTML, start)
+	}
+
+	start = lx.pos
+	rest := lx.src[lx.pos:]
+	switch {
+	case len(rest) >= 5 && strings.EqualFold(rest[:5], "<?php"):
+		lx.pos += 5
+	case strings.HasPrefix(rest, "<?="):
+		lx.pos += 3
+	default:
+		lx.pos += 2
+	}
+	lx.emit(TokenOpenTag, start)
+}
+
+// lexPHP consumes PHP code until a close tag or end of input.
+func (lx *lexer) lexPHP() {
+	for lx.pos < len(lx.src) {
+		start := lx.pos
+		c := lx.src[lx.pos]
+		rest := lx.src[lx.pos:]
+
+		switch {
+		case strings.HasPrefix(rest, "?>"):
+			lx.pos += 2
+			// a single newline directly after the close tag belongs to it
+			if strings.HasPrefix(lx.src[lx.pos:], "\r\n") {
+				lx.pos += 2
+			} else if lx.pos < len(lx.src) && lx.src[lx.pos] == '\n' {
+				lx.pos++
+			}
+			lx.emit(TokenCloseTag, start)
+			return
+
+		case isSpace(c):
+			for lx.pos < len(lx.src) && isSpace(lx.src[lx.pos]) {
+				lx.pos++
+			}
+			lx.emit(TokenWhitespace, start)
+
+		case strings.HasPrefix(rest, "/*"):
+			end := strings.Index(rest[2:], "*/")
+			if end < 0 {
+				lx.pos = len(lx.src)
+			} else {
+				lx.pos += end + 4
+			}
+			kind := TokenComment
+			if strings.HasPrefix(rest, "/**") && lx.pos-start > 4 {
+				kind = TokenDocComment
+			}
+			lx.emit(kind, start)
+
+		case strings.HasPrefix(rest, "//") || (c == '#' && !isAttribute(rest)):
+			lx.lexLineComment()
+			lx.emit(TokenComment, start)
+
+		case c == '\'' || c == '"':
+			lx.lexQuoted(c)
+			lx.emit(TokenString, start)
+
+		case c == '`':
+			lx.lexQuoted(c)
+			lx.emit(TokenString, start)
+
+		case strings.HasPrefix(rest, "<<<"):
+			if lx.lexHeredoc() {
+				lx.emit(TokenHeredoc, start)
+			} else {
+				lx.pos = start + 2
+				lx.emit(TokenOperator, start)
+			}
+
+		case c == '$' && lx.pos+1 < len(lx.src) && isIdentStart(lx.src[lx.pos+1]):
+			lx.pos++
+			lx.lexIdent()
+			lx.emit(TokenVariable, start)
+
+		case isDigit(c) || (c == '.' && lx.pos+1 < len(lx.src) && isDigit(lx.src[lx.pos+1])):
+			lx.lexNumber()
+			lx.emit(TokenNumber, start)
+
+		case isIdentStart(c) || c == '\\':
+			lx.lexIdent()
+			lx.emit(TokenIdent, start)
+
+		default:
+			lx.pos++
+			for _, op := range phpOperators {
+				if strings.HasPrefix(rest, op) {
+					lx.pos = start + len(op)
+					break
+				}
+			}
+			lx.emit(TokenOperator, start)
+		}
+	}
+}
+
+func (lx *lexer) lexLineComment() {
+	for lx.pos < len(lx.src) {
+		if lx.src[lx.pos] == '\n' {
+			return
+		}
+		// a close tag ends a single line comment
+		if strings.HasPrefix(lx.src[lx.pos:], "?>") {
+			return
+		}
+		lx.pos++
+	}
+}
+
+// isAttribute reports whether the "#" starting rest opens a PHP 8
+// attribute. Before PHP 8 "#[" starts a comment, so it is only an
+// attribute when its "]" closes on the same line and is followed by
+// nothing, another attribute or what attributes are put on.
+func isAttribute(rest string) bool {
+	if !strings.HasPrefix(rest, "#[") {
+		return false
+	}
+	line := rest
+	if nl := strings.IndexByte(rest, '\n'); nl >= 0 {
+		line = rest[:nl]
+	}
+
+	depth := 0
+	for i := 1; i < len(line); i++ {
+		switch line[i] {
+		case '[':
+			depth++
+		case ']':
+			depth--
+			if depth > 0 {
+				continue
+			}
+			after := strings.TrimSpace(line[i+1:])
+			if after == "" || strings.HasPrefix(after, "#[") || strings.ContainsRune("$&,)", rune(after[0])) {
+				return true
+			}
+			word := after
+			if end := strings.IndexFunc(after, func(r rune) bool { return !isIdentChar(byte(r)) }); end >= 0 {
+				word = after[:end]
+			}
+			return attributeTargets[strings.ToLower(word)]
+		}
+	}
+	return false
+}
+
+// keywords that may follow an attribute on the same line
+var attributeTargets = map[string]bool{
+	"public": true, "protected": true, "private": true, "static": true,
+	"abstract": true, "final": true, "readonly": true, "var": true, "const": true,
+	"function": true, "fn": true, "class": true, "interface": true, "trait": true, "enum": true,
+}
+
+func (lx *lexer) lexQuoted(quote byte) {
+	lx.pos++
+	for lx.pos < len(lx.src) {
+		c := lx.src[lx.pos]
+		if c == '\\' {
+			lx.pos += 2
+			continue
+		}
+		// "{$a["b"]}" and "${a["b"]}": the expression may contain quotes
+		if quote != '\'' && (strings.HasPrefix(lx.src[lx.pos:], "{$") || strings.HasPrefix(lx.src[lx.pos:], "${")) {
+			lx.lexInterpolation()
+			continue
+		}
+		lx.pos++
+		if c == quote {
+			return
+		}
+	}
+	lx.pos = len(lx.src)
+}
+
+// lexInterpolation consumes a "{$...}" or "${...}" expression inside a
+// double-quoted string, up to its closing brace.
+func (lx *lexer) lexInterpolation() {
+	depth := 0
+	for lx.pos < len(lx.src) {
+		switch c := lx.src[lx.pos]; c {
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				lx.pos++
+				return
+			}
+		case '\'', '"':
+			lx.lexQuoted(c)
+			continue
+		}
+		lx.pos++
+	}
+}
+
+// lexHeredoc consumes a heredoc or nowdoc. It returns false if the
+// "<<<" is not followed by a valid heredoc label.
+func (lx *lexer) lexHeredoc() bool {
+	p := lx.pos + 3
+	for p < len(lx.src) && (lx.src[p] == ' ' || lx.src[p] == '\t') {
+		p++
+	}
+
+	quote := byte(0)
+	if p < len(lx.src) && (lx.src[p] == '\'' || lx.src[p] == '"') {
+		quote = lx.src[p]
+		p++
+	}
+
+	labelStart := p
+	for p < len(lx.src) && isIdentChar(lx.src[p]) {
+		p++
+	}
+	label := lx.src[labelStart:p]
+	if label == "" || !isIdentStart(label[0]) {
+		return false
+	}
+	if quote != 0 {
+		if p >= len(lx.src) || lx.src[p] != quote {
+			return false
+		}
+		p++
+	}
+
+	nl := strings.IndexByte(lx.src[p:], '\n')
+	if nl < 0 {
+		return false
+	}
+	p += nl + 1
+
+	// the closing label may be indented (PHP 7.3+) and must not be
+	// followed by an identifier character
+	for p < len(lx.src) {
+		lineEnd := strings.IndexByte(lx.src[p:], '\n')
+		line := lx.src[p:]
+		if lineEnd >= 0 {
+			line = lx.src[p : p+lineEnd]
+		}
+		trimmed := strings.TrimLeft(line, " \t")
+		if strings.HasPrefix(trimmed, label) {
+			after := trimmed[len(label):]
+			if after == "" || !isIdentChar(after[0]) {
+				lx.pos = p + (len(line) - len(trimmed)) + len(label)
+				return true
+			}
+		}
+		if lineEnd < 0 {
+			break
+		}
+		p += lineEnd + 1
+	}
+
+	lx.pos = len(lx.src)
+	return true
+}
+
+func (lx *lexer) lexIdent() {
+	for lx.pos < len(lx.src) && (isIdentChar(lx.src[lx.pos]) || lx.src[lx.pos] == '\\') {
+		lx.pos++
+	}
+}
+
+func (lx *lexer) lexNumber() {
+	for lx.pos < len(lx.src) {
+		c := lx.src[lx.pos]
+		if isIdentChar(c) || c == '.' {
+			lx.pos++
+			continue
+		}
+		break
+	}
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f' || c == '\v'
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || c >= 0x80
+}
+
+func isIdentChar(c byte) bool {
+	return isIdentStart(c) || isDigit(c)
+}
+
+// ------------------------------------------------------------
+// TOKEN STREAM HELPERS
+// ------------------------------------------------------------
+
+// SignificantTokens drops whitespace, comments, inline HTML and PHP tags,
+// leaving only the tokens that carry code.
+func SignificantTokens(tokens []Token) []Token {
+	var result []Token
+	for _, t := range tokens {
+		switch t.Kind {
+		case TokenWhitespace, TokenComment, TokenDocComment,
+			TokenInlineHTML, TokenOpenTag, TokenCloseTag:
+			continue
+		}
+		result = append(result, t)
+	}
+	return result
+}
+
+// StripComments rebuilds the source from tokens with every comment blanked
+// out. Newlines inside comments are preserved so line numbers still match
+// the original file.
+func StripComments(tokens []Token) string {
+	var b strings.Builder
+	for _, t := range tokens {
+		if t.Kind == TokenComment || t.Kind == TokenDocComment {
+			b.WriteString(strings.Repeat("\n", strings.Count(t.Text, "\n")))
+			continue
+		}
+		b.WriteString(t.Text)
+	}
+	return b.String()
+}
+
+// matchingClose returns the index of the token that closes the bracket
+// opened at tokens[open], or len(tokens)-1 if it is never closed.
+func matchingClose(tokens []Token, open int) int {
+	var closeOp string
+	openOp := tokens[open].Text
+	switch openOp {
+	case "(":
+		closeOp = ")"
+	case "[":
+		closeOp = "]"
+	case "{":
+		closeOp = "}"
+	default:
+		return open
+	}
+
+	level := 0
+	for i := open; i < len(tokens); i++ {
+		if tokens[i].Kind != TokenOperator {
+			continue
+		}
+		switch tokens[i].Text {
+		case openOp:
+			level++
+		case closeOp:
+			level--
+			if level == 0 {
+				return i
+			}
+		}
+	}
+	return len(tokens) - 1
+}
@@ -0,0 +1,75 @@
+package analyzer
+
+import "testing"
+
+// significant returns the text of the tokens that are not whitespace or
+// comments.
+func significant(code string) []string {
+	var texts []string
+	for _, t := range SignificantTokens(Tokenize(code)) {
+		texts = append(texts, t.Text)
+	}
+	return texts
+}
+
+func TestTokenizeHashBracket(t *testing.T) {
+	tests := []struct {
+		name string
+		code string
+		want []string
+	}{
+		{
+			name: "PHP 7 comment",
+			code: "<?php\n#[todo] fix this\n$a = 1;",
+			want: []string{"$a", "=", "1", ";"},
+		},
+		{
+			name: "comment without a closing bracket",
+			code: "<?php\n#[ the users table\n$a = 1;",
+			want: []string{"$a", "=", "1", ";"},
+		},
+		{
+			name: "attribute on its own line",
+			code: "<?php\n#[Deprecated]\nfunction f() {}",
+			want: []string{"#[", "Deprecated", "]", "function", "f", "(", ")", "{", "}"},
+		},
+		{
+			name: "attribute before a modifier",
+			code: "<?php #[Route('/x')] public function f() {}",
+			want: []string{"#[", "Route", "(", "'/x'", ")", "]", "public", "function", "f", "(", ")", "{", "}"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := significant(tt.code)
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %q, want %q", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("got %q, want %q", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestTokenizeInterpolatedQuotes(t *testing.T) {
+	tests := []string{
+		`"Hello {$user["name"]}!"`,
+		`"Hello ${user["name"]}!"`,
+		`"Row {$rows[$i]['id']} of {$this->data["total"]}"`,
+	}
+
+	for _, str := range tests {
+		tokens := SignificantTokens(Tokenize("<?php $s = " + str + "; $b = 2;"))
+		if len(tokens) < 3 || tokens[2].Kind != TokenString || tokens[2].Text != str {
+			t.Errorf("%s: tokens = %+v", str, tokens)
+			continue
+		}
+		if got := len(tokens); got != 8 {
+			t.Errorf("%s: %d tokens, want 8", str, got)
+		}
+	}
+}
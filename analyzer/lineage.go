@@ -0,0 +1,102 @@
+/*
+Copyright © 2025 Vicky Chhetri <vickychhetri4@gmail.com>
+
+End-to-end lineage: URL route → controller method → model method → table
+*/
+
+package analyzer
+
+import "strings"
+
+type LineageRow struct {
+	URL              string
+	Verb             string
+	Explicit         bool
+	Module           string
+	Controller       string // controller class
+	ControllerMethod string
+	ControllerFile   string
+	Model            string // model class
+	ModelMethod      string
+	ModelFile        string
+	Via              string
+	Table            string
+	Operation        string
+	Source           string
+	Connection       string
+}
+
+// findClass returns the declaration of a class in a given file.
+func findClass(reports []ModuleReport, file, name string) *PHPClass {
+	for r := range reports {
+		for f := range reports[r].Files {
+			if reports[r].Files[f].FilePathStr != file {
+				continue
+			}
+			for c := range reports[r].Files[f].Classes {
+				class := &reports[r].Files[f].Classes[c]
+				if strings.EqualFold(class.ClassName, name) {
+					return class
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// BuildLineage follows every resolved route into its controller method,
+// the model methods called from it ($this->some_model->method(), also
+// through the controller's own helper methods) and the tables those model
+// methods touch. Run ResolveInheritance on the reports first.
+func BuildLineage(idx *CallIndex, routes *RouteTable, reports []ModuleReport) []LineageRow {
+	var rows []LineageRow
+
+	for _, route := range routes.Routes {
+		if !route.Resolved() {
+			continue
+		}
+		controller := findClass(reports, route.File, route.Class)
+		if controller == nil {
+			continue
+		}
+		action, ok := controller.FindMethod(route.Method)
+		if !ok {
+			continue
+		}
+
+		mappings, _ := methodMappings(controller, action, route.Module, idx)
+		for _, m := range mappings {
+			rows = append(rows, LineageRow{
+				URL:              route.Pattern,
+				Verb:             route.Verb,
+				Explicit:         route.Explicit,
+				Module:           route.Module,
+				Controller:       route.Class,
+				ControllerMethod: action.Name,
+				ControllerFile:   route.File,
+				Model:            m.Model,
+				ModelMethod:      m.ModelMethod,
+				ModelFile:        m.ModelFile,
+				Via:              m.Via,
+				Table:            m.Table,
+				Operation:        m.Operation,
+				Source:           m.Source,
+				Connection:       m.Connection,
+			})
+		}
+	}
+
+	return uniqueLineage(rows)
+}
+
+func uniqueLineage(rows []LineageRow) []LineageRow {
+	seen := map[LineageRow]bool{}
+	var result []LineageRow
+	for _, r := range rows {
+		if !seen[r] {
+			seen[r] = true
+			result = append(result, r)
+		}
+	}
+	return result
+}
@@ -8,101 +8,533 @@ Supports Query Builder + Raw SQL
 package analyzer
 
 import (
-	"regexp"
+	"strconv"
+	"strings"
 )
 
 // ------------------------------------------------------------
-// REGEX DEFINITIONS (CI3 REAL-WORLD SUPPORT)
-// ------------------------------------------------------------
-
-// $this->load->model('User_model');
-var loadModelRegex = regexp.MustCompile(
-	`\$this->load->model\(\s*['"]([^'"]+)['"]`,
-)
-
-// Query Builder table usage
-// from(), get(), get_where(), insert(), update(), delete(), join()
-var qbTableRegex = regexp.MustCompile(
-	`->(from|get|get_where|insert|update|delete|join)\(\s*['"]([^'"]+)['"]`,
-)
-
-// Raw SQL queries
-// $this->db->query("SELECT ...");
-var rawSQLRegexQuery = regexp.MustCompile(
-	`\$this->db->query\(\s*["']([\s\S]*?)["']\s*\)`,
-)
-
-// SQL table extraction
-// FROM table | JOIN table | INSERT INTO table | UPDATE table
-var sqlTableRegex = regexp.MustCompile(
-	`(?i)\b(from|join|into|update)\s+([a-zA-Z0-9_]+)`,
-)
-
-// ------------------------------------------------------------
-// DATA STRUCTURE (optional future use)
+// DATA STRUCTURE
 // ------------------------------------------------------------
 
+// Mapping is one controller method → model method → table edge.
+// ControllerMethod and ModelMethod are empty when a controller loads a
+// model without any call that could be resolved to a model method; the
+// row then covers every table of the model file.
 type Mapping struct {
-	Controller string
-	Model      string
-	Table      string
-	File       string
-	Line       int
+	Module           string
+	Controller       string // controller file name, e.g. Users.php
+	ControllerMethod string
+	Model            string
+	ModelMethod      string
+	Table            string
+	Operation        string // SELECT, INSERT, UPDATE, DELETE, ...
+	Source           string // query_builder or raw_sql
+	Connection       string // database.php group the query runs on
+	ControllerFile   string
+	ModelFile        string
+	Via              string // libraries and helpers the call goes through, e.g. "Mailer::send → notify()"
+	Line             int    // line of the model call in the controller
 }
 
 // ------------------------------------------------------------
 // CONTROLLER → MODELS
 // ------------------------------------------------------------
 
+// ModelLoad is one model loaded with $this->load->model().
+type ModelLoad struct {
+	Module  string // first segment of a path like "users/User_model": an HMVC module or a models/ sub-directory
+	Path    string // as passed to the loader, e.g. "users/User_model"
+	Class   string // model class, e.g. User_model
+	Alias   string // property the model is assigned to, defaults to the last path segment
+	Dynamic bool   // name computed at runtime, Path then holds the expression
+	Line    int
+}
+
+// UnresolvedLoad is a model load whose model file could not be found.
+type UnresolvedLoad struct {
+	Module string
+	File   string
+	Class  string // class doing the load
+	Load   ModelLoad
+	Reason string
+}
+
+func newModelLoad(path, alias string, line int) ModelLoad {
+	path = strings.Trim(path, "/")
+	load := ModelLoad{Path: path, Alias: alias, Line: line}
+
+	name := path
+	if i := strings.LastIndex(path, "/"); i >= 0 {
+		name = path[i+1:]
+		load.Module = path[:strings.Index(path, "/")]
+	}
+	if load.Alias == "" {
+		load.Alias = name
+	}
+	if name != "" {
+		load.Class = strings.ToUpper(name[:1]) + name[1:]
+	}
+	return load
+}
+
 // ExtractModels finds all models loaded in a controller
-func ExtractModels(code string) []string {
-	var models []string
+func ExtractModels(code string) []ModelLoad {
+	return ExtractModelsFromTokens(Tokenize(code))
+}
+
+// ExtractModelsFromTokens finds all models loaded in an already tokenized
+// controller. Loads inside comments and strings are ignored. Handles
+//
+//	$this->load->model('Name')
+//	$this->load->model('module/sub/Name', 'alias')
+//	$this->load->model(array('a_model', 'b_model' => 'b'))
+//	$this->load->model($name)
+//	$CI->load->model('Name') (any get_instance() handle)
+//
+// Variables assigned a literal in the same code are resolved; anything
+// else is returned as a Dynamic load.
+func ExtractModelsFromTokens(tokens []Token) []ModelLoad {
+	return extractLoads(tokens, "model", 1)
+}
 
-	matches := loadModelRegex.FindAllStringSubmatch(code, -1)
-	for _, m := range matches {
-		models = append(models, m[1])
+// ExtractLibrariesFromTokens finds the libraries loaded with
+// $this->load->library('name', $params, 'alias'). Libraries are assigned
+// to the lowercase class name unless an alias is given.
+func ExtractLibrariesFromTokens(tokens []Token) []ModelLoad {
+	loads := extractLoads(tokens, "library", 2)
+	for i := range loads {
+		if !loads[i].Dynamic && strings.EqualFold(loads[i].Alias, loads[i].Class) {
+			loads[i].Alias = strings.ToLower(loads[i].Alias)
+		}
+	}
+	return loads
+}
+
+// extractLoads finds the ->load->loader() calls in tokens; aliasArg is the
+// position of the argument naming the property the object is assigned to.
+func extractLoads(tokens []Token, loader string, aliasArg int) []ModelLoad {
+	var loads []ModelLoad
+
+	// evaluate "$name = 'literal';" statements so $this->load->model($name)
+	// resolves when the name is known statically
+	e := &configEval{
+		sig: SignificantTokens(tokens),
+		cfg: &PHPConfig{Vars: map[string]any{}},
+	}
+	for i := 0; i < len(e.sig); {
+		i = e.statement(i)
 	}
 
-	return unique(models)
+	sig := e.sig
+	for i := 0; i+5 < len(sig); i++ {
+		if !sig[i].IsOp("->") || !sig[i+1].IsKeyword("load") ||
+			!sig[i+2].IsOp("->") || !sig[i+3].IsKeyword(loader) ||
+			!sig[i+4].IsOp("(") {
+			continue
+		}
+		line := sig[i+3].Line
+		args := splitArgs(sig, i+4)
+		if len(args) == 0 {
+			continue
+		}
+
+		alias := ""
+		if len(args) > aliasArg {
+			if v, ok := e.expr(args[aliasArg][0], args[aliasArg][1]); ok {
+				alias = configString(v)
+			}
+		}
+
+		v, ok := e.expr(args[0][0], args[0][1])
+		if !ok {
+			loads = append(loads, ModelLoad{Path: tokenText(sig[args[0][0]:args[0][1]]), Dynamic: true, Line: line})
+			continue
+		}
+
+		arr, isArr := v.(*PHPArray)
+		if !isArr {
+			loads = append(loads, newModelLoad(configString(v), alias, line))
+			continue
+		}
+
+		// array('name', 'name' => 'alias')
+		for _, key := range arr.Keys() {
+			item, _ := arr.Get(key)
+			switch {
+			case item == nil:
+				loads = append(loads, ModelLoad{Path: "?", Dynamic: true, Line: line})
+			case isIntKey(key):
+				loads = append(loads, newModelLoad(configString(item), "", line))
+			default:
+				loads = append(loads, newModelLoad(key, configString(item), line))
+			}
+		}
+	}
+
+	return uniqueLoads(loads)
+}
+
+// splitArgs returns the [from, to) ranges of the arguments of the call
+// whose "(" is at sig[open].
+func splitArgs(sig []Token, open int) [][2]int {
+	var args [][2]int
+
+	closeIdx := matchingClose(sig, open)
+	start := open + 1
+	for i := start; i <= closeIdx && i < len(sig); i++ {
+		if i < closeIdx {
+			t := sig[i]
+			if t.IsOp("(") || t.IsOp("[") {
+				i = matchingClose(sig, i)
+				continue
+			}
+			if !t.IsOp(",") {
+				continue
+			}
+		}
+		if start < i {
+			args = append(args, [2]int{start, i})
+		}
+		start = i + 1
+	}
+
+	return args
+}
+
+func tokenText(tokens []Token) string {
+	var b strings.Builder
+	for _, t := range tokens {
+		b.WriteString(t.Text)
+	}
+	return b.String()
+}
+
+func isIntKey(key string) bool {
+	_, err := strconv.ParseInt(key, 10, 64)
+	return err == nil
+}
+
+func uniqueLoads(loads []ModelLoad) []ModelLoad {
+	seen := map[string]bool{}
+	var result []ModelLoad
+	for _, l := range loads {
+		key := strings.ToLower(l.Path + "|" + l.Alias)
+		if l.Path == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		result = append(result, l)
+	}
+	return result
 }
 
 // ------------------------------------------------------------
-// MODEL → TABLES (MAIN LOGIC)
+// CONTROLLER METHOD → MODEL METHOD → TABLES
 // ------------------------------------------------------------
 
-// ExtractTables finds all tables used in a model file
-func ExtractTables(code string) []string {
-	var tables []string
+// BuildMapping maps every controller method to the model methods it calls
+// and the tables those model methods touch, using the index built from the
+// same reports. Run ResolveInheritance on the reports first so inherited
+// helper and model methods are followed. Model loads that cannot be
+// resolved to a model file are returned separately.
+func BuildMapping(idx *CallIndex, reports []ModuleReport) ([]Mapping, []UnresolvedLoad) {
+	var mappings []Mapping
+	var unresolved []UnresolvedLoad
+
+	for r := range reports {
+		module := reports[r].Module
+		for f := range reports[r].Files {
+			file := &reports[r].Files[f]
+			if !reports[r].inDir(file.FilePathStr, "controllers") {
+				continue
+			}
+
+			for c := range file.Classes {
+				controller := &file.Classes[c]
+				called := map[string]bool{}
+
+				var missing []UnresolvedLoad
+
+				for _, method := range controller.Methods {
+					rows, calls := methodMappings(controller, method, module, idx)
+					for _, m := range rows {
+						m.Controller = file.File
+						m.ControllerFile = file.FilePathStr
+						called[strings.ToLower(m.Model)] = true
+						mappings = append(mappings, m)
+					}
+					missing = append(missing, calls...)
+				}
+
+				// models that are loaded but never called through a
+				// resolvable $this->model->method() keep a file level row
+				reported := map[string]bool{}
+				for _, load := range controller.ModelLoads() {
+					model, ok := idx.models.resolve(load, module)
+					if !ok {
+						reason := "model not found"
+						if load.Dynamic {
+							reason = "dynamic model name"
+						}
+						reported[strings.ToLower(load.Alias)] = true
+						unresolved = append(unresolved, UnresolvedLoad{
+							Module: module,
+							File:   file.FilePathStr,
+							Class:  controller.ClassName,
+							Load:   load,
+							Reason: reason,
+						})
+						continue
+					}
+					if called[strings.ToLower(model.class.ClassName)] {
+						continue
+					}
+					for _, ref := range model.class.TableRefs(idx.tables) {
+						mappings = append(mappings, Mapping{
+							Module:         module,
+							Controller:     file.File,
+							Model:          model.class.ClassName,
+							Table:          ref.Table,
+							Operation:      ref.Operation,
+							Source:         ref.Source,
+							Connection:     ref.Connection,
+							ControllerFile: file.FilePathStr,
+							ModelFile:      model.file,
+						})
+					}
+				}
+
+				// calls to a model of another module that was not loaded here
+				for _, u := range missing {
+					if key := strings.ToLower(u.Load.Alias); !reported[key] {
+						reported[key] = true
+						u.File = file.FilePathStr
+						unresolved = append(unresolved, u)
+					}
+				}
+			}
+		}
+	}
+
+	return mappings, unresolved
+}
+
+// CallIndex holds what is needed to follow the calls of a controller
+// method: the models, the libraries, the helper functions and the
+// database table naming. Build it once per run with BuildCallIndex and
+// share it between BuildMapping, BuildColumnMapping and BuildLineage.
+type CallIndex struct {
+	tables    TableContext
+	models    *loadIndex
+	libraries *loadIndex
+	helpers   map[string]helperFunc // lowercase function name
+}
+
+type helperFunc struct {
+	fn     PHPMethod
+	file   string
+	module string
+}
+
+func BuildCallIndex(projectPath string, reports []ModuleReport) *CallIndex {
+	idx := &CallIndex{
+		tables:    DBTableContext(projectPath),
+		models:    buildModelIndex(projectPath, reports),
+		libraries: buildLibraryIndex(projectPath, reports),
+		helpers:   map[string]helperFunc{},
+	}
+
+	for r := range reports {
+		for f := range reports[r].Files {
+			file := &reports[r].Files[f]
+			if !reports[r].inDir(file.FilePathStr, "helpers") {
+				continue
+			}
+			for _, fn := range file.Functions {
+				key := strings.ToLower(fn.Name)
+				if _, ok := idx.helpers[key]; !ok {
+					idx.helpers[key] = helperFunc{fn: fn, file: file.FilePathStr, module: reports[r].Module}
+				}
+			}
+		}
+	}
+
+	return idx
+}
+
+// objectScope holds the objects assigned to the CI super object, by
+// lowercase property name. The super object is shared, so a library or a
+// helper sees everything the controller loaded.
+type objectScope struct {
+	models    map[string]loadEntry
+	libraries map[string]loadEntry
+}
+
+// with returns a copy of the scope extended with the given loads,
+// resolved from module.
+func (s objectScope) with(idx *CallIndex, modelLoads, libraryLoads []ModelLoad, module string) objectScope {
+	next := objectScope{models: map[string]loadEntry{}, libraries: map[string]loadEntry{}}
+	for k, v := range s.models {
+		next.models[k] = v
+	}
+	for k, v := range s.libraries {
+		next.libraries[k] = v
+	}
+	for _, l := range modelLoads {
+		if entry, ok := idx.models.resolve(l, module); ok {
+			next.models[strings.ToLower(l.Alias)] = entry
+		}
+	}
+	for _, l := range libraryLoads {
+		if entry, ok := idx.libraries.resolve(l, module); ok {
+			next.libraries[strings.ToLower(l.Alias)] = entry
+		}
+	}
+	return next
+}
+
+// callWalker follows the calls of one controller method into models,
+// libraries and helpers and collects a mapping row per model table.
+type callWalker struct {
+	idx        *CallIndex
+	module     string
+	class      string
+	method     string
+	rows       []Mapping
+	unresolved []UnresolvedLoad // calls to models only found in other modules
+	seen       map[string]bool  // model methods already mapped
+	visited    map[string]bool  // library methods and helpers already followed
+}
 
-	// --------------------------------------------
-	// 1. Query Builder (CI3 style)
-	// --------------------------------------------
-	qbMatches := qbTableRegex.FindAllStringSubmatch(code, -1)
-	for _, m := range qbMatches {
-		// m[2] = table name
-		tables = append(tables, m[2])
+// methodMappings resolves the model calls reachable from one controller
+// method into mapping rows (one per table). Calls are followed through
+// model aliases, get_instance() handles, library methods and helper
+// functions; Via records the libraries and helpers passed on the way.
+// Calls to a model that is neither loaded nor declared in the module, the
+// application or third_party are returned as unresolved loads.
+func methodMappings(controller *PHPClass, method PHPMethod, module string, idx *CallIndex) ([]Mapping, []UnresolvedLoad) {
+	w := &callWalker{
+		idx:     idx,
+		module:  module,
+		class:   controller.ClassName,
+		method:  method.Name,
+		seen:    map[string]bool{},
+		visited: map[string]bool{},
 	}
+	scope := objectScope{}.with(idx, controller.ModelLoads(), controller.LibraryLoads(), module)
+	w.follow(controller.reachableCalls(method), scope, module, nil)
+	return w.rows, w.unresolved
+}
 
-	// --------------------------------------------
-	// 2. Raw SQL ($this->db->query)
-	// --------------------------------------------
-	rawMatches := rawSQLRegexQuery.FindAllStringSubmatch(code, -1)
-	for _, m := range rawMatches {
-		sql := m[1]
+func (w *callWalker) follow(calls []MethodCall, scope objectScope, module string, via []string) {
+	for _, call := range calls {
+		if call.Function {
+			w.helper(call, scope, via)
+			continue
+		}
+
+		key := strings.ToLower(call.Property)
+		if model, ok := scope.models[key]; ok {
+			w.model(call, model, via)
+			continue
+		}
+		if library, ok := scope.libraries[key]; ok {
+			w.library(call, library, scope, via)
+			continue
+		}
 
-		sqlMatches := sqlTableRegex.FindAllStringSubmatch(sql, -1)
-		for _, sm := range sqlMatches {
-			// sm[2] = table name
-			tables = append(tables, sm[2])
+		// loaded elsewhere (e.g. by a parent controller or an autoload):
+		// fall back to the class named like the property
+		if model, ok := w.idx.models.lookup(call.Property, module); ok {
+			w.model(call, model, via)
+		} else if library, ok := w.idx.libraries.lookup(call.Property, module); ok {
+			w.library(call, library, scope, via)
+		} else if modules := w.idx.models.modulesOf(call.Property); len(modules) > 0 {
+			w.unresolved = append(w.unresolved, UnresolvedLoad{
+				Module: w.module,
+				Class:  w.class,
+				Load:   newModelLoad(call.Property, call.Property, call.Line),
+				Reason: "model only found in other modules: " + strings.Join(modules, ", "),
+			})
 		}
 	}
+}
+
+func (w *callWalker) model(call MethodCall, model loadEntry, via []string) {
+	modelMethod, ok := model.class.FindMethod(call.Method)
+	if !ok {
+		return
+	}
+
+	key := strings.ToLower(model.class.ClassName + "::" + modelMethod.Name)
+	if w.seen[key] {
+		return
+	}
+	w.seen[key] = true
 
-	sqlMatchesFrom := sqlTableRegex.FindAllStringSubmatch(code, -1)
-	for _, sm := range sqlMatchesFrom {
-		// sm[2] = table name
-		tables = append(tables, sm[2])
+	row := Mapping{
+		Module:           w.module,
+		ControllerMethod: w.method,
+		Model:            model.class.ClassName,
+		ModelMethod:      modelMethod.Name,
+		ModelFile:        model.file,
+		Via:              strings.Join(via, " → "),
+		Line:             call.Line,
 	}
-	return unique(tables)
+
+	refs := model.class.methodTableRefs(modelMethod, w.idx.tables)
+	if len(refs) == 0 {
+		w.rows = append(w.rows, row)
+		return
+	}
+	for _, ref := range refs {
+		row.Table = ref.Table
+		row.Operation = ref.Operation
+		row.Source = ref.Source
+		row.Connection = ref.Connection
+		w.rows = append(w.rows, row)
+	}
+}
+
+func (w *callWalker) library(call MethodCall, library loadEntry, scope objectScope, via []string) {
+	method, ok := library.class.FindMethod(call.Method)
+	if !ok {
+		return
+	}
+
+	step := library.class.ClassName + "::" + method.Name
+	key := strings.ToLower(library.file + "|" + step)
+	if w.visited[key] {
+		return
+	}
+	w.visited[key] = true
+
+	w.follow(
+		library.class.reachableCalls(method),
+		scope.with(w.idx, library.class.ModelLoads(), library.class.LibraryLoads(), library.module),
+		library.module,
+		append(via[:len(via):len(via)], step),
+	)
+}
+
+func (w *callWalker) helper(call MethodCall, scope objectScope, via []string) {
+	helper, ok := w.idx.helpers[strings.ToLower(call.Method)]
+	if !ok {
+		return
+	}
+
+	key := "function|" + strings.ToLower(helper.fn.Name)
+	if w.visited[key] {
+		return
+	}
+	w.visited[key] = true
+
+	w.follow(
+		bodyCalls(helper.fn.body, nil),
+		scope.with(w.idx, ExtractModelsFromTokens(helper.fn.body), ExtractLibrariesFromTokens(helper.fn.body), helper.module),
+		helper.module,
+		append(via[:len(via):len(via)], helper.fn.Name+"()"),
+	)
 }
 
 // ------------------------------------------------------------
@@ -1,11 +1,29 @@
 package analyzer
 
-import "database/sql"
+import (
+	"database/sql"
+	"fmt"
+)
+
+// Report statuses. A report is running until all of its rows are
+// written; only complete reports are picked as the latest one.
+const (
+	ReportRunning  = "running"
+	ReportComplete = "complete"
+	ReportFailed   = "failed"
+)
+
+// createReport starts a report of the given type for a project, which is
+// registered in projects if it is new.
+func createReport(db *sql.DB, reportType, projectPath string) (int64, error) {
+	projectID, err := ensureProject(db, projectPath)
+	if err != nil {
+		return 0, err
+	}
 
-func CreateReport(db *sql.DB, reportType, projectPath string) (int64, error) {
 	res, err := db.Exec(
-		`INSERT INTO reports (type, project_path) VALUES (?, ?)`,
-		reportType, projectPath,
+		`INSERT INTO reports (type, project_path, project_id, status) VALUES (?, ?, ?, ?)`,
+		reportType, projectPath, projectID, ReportRunning,
 	)
 	if err != nil {
 		return 0, err
@@ -13,29 +31,336 @@ func CreateReport(db *sql.DB, reportType, projectPath string) (int64, error) {
 	return res.LastInsertId()
 }
 
-func SaveMapping(
-	db *sql.DB,
-	reportID int64,
-	module string,
-	controller string,
-	model string,
-	table string,
-	controllerFile string,
-	modelFile string,
-) error {
+// completeReport marks a report whose rows are all written as complete.
+func completeReport(db *sql.DB, reportID int64) error {
+	_, err := db.Exec(
+		`UPDATE reports SET status = ?, finished_at = CURRENT_TIMESTAMP, error = NULL WHERE id = ?`,
+		ReportComplete, reportID,
+	)
+	return err
+}
 
-	_, err := db.Exec(`
+// failReport marks a report as failed with the error that stopped it.
+func failReport(db *sql.DB, reportID int64, cause error) error {
+	msg := ""
+	if cause != nil {
+		msg = cause.Error()
+	}
+	_, err := db.Exec(
+		`UPDATE reports SET status = ?, finished_at = CURRENT_TIMESTAMP, error = ? WHERE id = ?`,
+		ReportFailed, msg, reportID,
+	)
+	return err
+}
+
+// latestReportID returns the most recent complete report of the given
+// type.
+func latestReportID(db *sql.DB, reportType string) (int64, error) {
+	var id int64
+	err := db.QueryRow(
+		`SELECT id FROM reports WHERE type = ? AND status = ? ORDER BY id DESC LIMIT 1`,
+		reportType, ReportComplete,
+	).Scan(&id)
+	if err == sql.ErrNoRows {
+		return 0, noReportError(reportType, "")
+	}
+	return id, err
+}
+
+// latestProjectReportID returns the most recent complete report of the
+// given type for a project.
+func latestProjectReportID(db *sql.DB, reportType, projectPath string) (int64, error) {
+	var id int64
+	err := db.QueryRow(`
+	SELECT r.id FROM reports r
+	JOIN projects p ON p.id = r.project_id
+	WHERE r.type = ? AND r.status = ? AND p.root_path = ?
+	ORDER BY r.id DESC LIMIT 1`,
+		reportType, ReportComplete, CanonicalProjectPath(projectPath),
+	).Scan(&id)
+	if err == sql.ErrNoRows {
+		return 0, noReportError(reportType, projectPath)
+	}
+	return id, err
+}
+
+const insertMappingSQL = `
 	INSERT INTO controller_model_table_map
-	(report_id, module, controller, model, table_name, controller_file, model_file)
-	VALUES (?, ?, ?, ?, ?, ?, ?)`,
-		reportID,
-		module,
-		controller,
-		model,
-		table,
-		controllerFile,
-		modelFile,
+	(report_id, module, controller, controller_method, model, model_method, table_name, operation, source, connection, controller_file, model_file, via)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+
+func mappingArgs(reportID int64, m Mapping) []any {
+	return []any{
+		reportID,
+		m.Module,
+		m.Controller,
+		m.ControllerMethod,
+		m.Model,
+		m.ModelMethod,
+		m.Table,
+		m.Operation,
+		m.Source,
+		m.Connection,
+		m.ControllerFile,
+		m.ModelFile,
+		m.Via,
+	}
+}
+
+func saveMapping(db *sql.DB, reportID int64, m Mapping) error {
+	_, err := db.Exec(insertMappingSQL, mappingArgs(reportID, m)...)
+	return err
+}
+
+// loadMappings returns the controller → model → table rows of a report.
+func loadMappings(db *sql.DB, reportID int64) ([]Mapping, error) {
+	rows, err := db.Query(`
+	SELECT module, controller, controller_method, model, model_method,
+		table_name, operation, source, connection, controller_file, model_file, via
+	FROM controller_model_table_map
+	WHERE report_id = ?
+	ORDER BY id`,
+		reportID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var mappings []Mapping
+	for rows.Next() {
+		var m Mapping
+		var controllerMethod, modelMethod, operation, source, connection, via sql.NullString
+		if err := rows.Scan(
+			&m.Module,
+			&m.Controller,
+			&controllerMethod,
+			&m.Model,
+			&modelMethod,
+			&m.Table,
+			&operation,
+			&source,
+			&connection,
+			&m.ControllerFile,
+			&m.ModelFile,
+			&via,
+		); err != nil {
+			return nil, err
+		}
+		m.ControllerMethod = controllerMethod.String
+		m.ModelMethod = modelMethod.String
+		m.Operation = operation.String
+		m.Source = source.String
+		m.Connection = connection.String
+		m.Via = via.String
+		mappings = append(mappings, m)
+	}
+
+	return mappings, rows.Err()
+}
+
+const insertRouteSQL = `
+	INSERT INTO routes
+	(report_id, pattern, verb, target, module, controller, class, method, controller_file, explicit, source)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+
+func routeArgs(reportID int64, route Route) []any {
+	return []any{
+		reportID,
+		route.Pattern,
+		route.Verb,
+		route.Target,
+		route.Module,
+		route.Controller,
+		route.Class,
+		route.Method,
+		route.File,
+		route.Explicit,
+		route.Source,
+	}
+}
+
+func saveRoute(db *sql.DB, reportID int64, route Route) error {
+	_, err := db.Exec(insertRouteSQL, routeArgs(reportID, route)...)
+	return err
+}
+
+const insertLineageSQL = `
+	INSERT INTO route_lineage
+	(report_id, url, verb, explicit, module, controller, controller_method, controller_file, model, model_method, model_file, via, table_name, operation, source, connection)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+
+func lineageArgs(reportID int64, row LineageRow) []any {
+	return []any{
+		reportID,
+		row.URL,
+		row.Verb,
+		row.Explicit,
+		row.Module,
+		row.Controller,
+		row.ControllerMethod,
+		row.ControllerFile,
+		row.Model,
+		row.ModelMethod,
+		row.ModelFile,
+		row.Via,
+		row.Table,
+		row.Operation,
+		row.Source,
+		row.Connection,
+	}
+}
+
+func saveLineage(db *sql.DB, reportID int64, row LineageRow) error {
+	_, err := db.Exec(insertLineageSQL, lineageArgs(reportID, row)...)
+	return err
+}
+
+// loadLineage returns the route → table rows of a report.
+func loadLineage(db *sql.DB, reportID int64) ([]LineageRow, error) {
+	rows, err := db.Query(`
+	SELECT url, verb, explicit, module, controller, controller_method, controller_file,
+		model, model_method, model_file, via, table_name, operation, source, connection
+	FROM route_lineage
+	WHERE report_id = ?
+	ORDER BY id`,
+		reportID,
 	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var lineage []LineageRow
+	for rows.Next() {
+		var r LineageRow
+		var verb, via, operation, source, connection sql.NullString
+		if err := rows.Scan(
+			&r.URL,
+			&verb,
+			&r.Explicit,
+			&r.Module,
+			&r.Controller,
+			&r.ControllerMethod,
+			&r.ControllerFile,
+			&r.Model,
+			&r.ModelMethod,
+			&r.ModelFile,
+			&via,
+			&r.Table,
+			&operation,
+			&source,
+			&connection,
+		); err != nil {
+			return nil, err
+		}
+		r.Verb = verb.String
+		r.Via = via.String
+		r.Operation = operation.String
+		r.Source = source.String
+		r.Connection = connection.String
+		lineage = append(lineage, r)
+	}
+
+	return lineage, rows.Err()
+}
+
+// reportProjectPath returns the project path a report was made for.
+func reportProjectPath(db *sql.DB, reportID int64) (string, error) {
+	var path string
+	err := db.QueryRow(`SELECT project_path FROM reports WHERE id = ?`, reportID).Scan(&path)
+	if err == sql.ErrNoRows {
+		return "", fmt.Errorf("report %d not found", reportID)
+	}
+	return path, err
+}
+
+const insertUnresolvedLoadSQL = `
+	INSERT INTO unresolved_model_loads
+	(report_id, module, file, class, line, path, alias, dynamic, reason)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`
 
+func unresolvedLoadArgs(reportID int64, u UnresolvedLoad) []any {
+	return []any{
+		reportID,
+		u.Module,
+		u.File,
+		u.Class,
+		u.Load.Line,
+		u.Load.Path,
+		u.Load.Alias,
+		u.Load.Dynamic,
+		u.Reason,
+	}
+}
+
+func saveUnresolvedLoad(db *sql.DB, reportID int64, u UnresolvedLoad) error {
+	_, err := db.Exec(insertUnresolvedLoadSQL, unresolvedLoadArgs(reportID, u)...)
 	return err
 }
+
+const insertColumnMappingSQL = `
+	INSERT INTO model_column_map
+	(report_id, module, model, model_method, model_file, table_name, column_name, operation, clause, source, connection, line)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+
+func columnMappingArgs(reportID int64, c ColumnMapping) []any {
+	return []any{
+		reportID,
+		c.Module,
+		c.Model,
+		c.ModelMethod,
+		c.ModelFile,
+		c.Table,
+		c.Column,
+		c.Operation,
+		c.Clause,
+		c.Source,
+		c.Connection,
+		c.Line,
+	}
+}
+
+func saveColumnMapping(db *sql.DB, reportID int64, c ColumnMapping) error {
+	_, err := db.Exec(insertColumnMappingSQL, columnMappingArgs(reportID, c)...)
+	return err
+}
+
+// loadColumnMappings returns the model method → column rows of a report.
+func loadColumnMappings(db *sql.DB, reportID int64) ([]ColumnMapping, error) {
+	rows, err := db.Query(`
+	SELECT module, model, model_method, model_file, table_name, column_name,
+		operation, clause, source, connection, line
+	FROM model_column_map
+	WHERE report_id = ?
+	ORDER BY id`,
+		reportID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var columns []ColumnMapping
+	for rows.Next() {
+		var c ColumnMapping
+		if err := rows.Scan(
+			&c.Module,
+			&c.Model,
+			&c.ModelMethod,
+			&c.ModelFile,
+			&c.Table,
+			&c.Column,
+			&c.Operation,
+			&c.Clause,
+			&c.Source,
+			&c.Connection,
+			&c.Line,
+		); err != nil {
+			return nil, err
+		}
+		columns = append(columns, c)
+	}
+
+	return columns, rows.Err()
+}
@@ -0,0 +1,319 @@
+/*
+Copyright © 2025 Vicky Chhetri <vickychhetri4@gmail.com>
+
+MemoryStore: a Store that keeps its reports in memory.
+*/
+
+package analyzer
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// MemoryStore is a Store that keeps its reports in memory. It is safe for
+// concurrent use.
+type MemoryStore struct {
+	mu       sync.Mutex
+	reports  []*memoryReport
+	projects []Project
+}
+
+// memoryRows are the rows of a report.
+type memoryRows struct {
+	mappings   []Mapping
+	unresolved []UnresolvedLoad
+	columns    []ColumnMapping
+	routes     []Route
+	lineage    []LineageRow
+	schema     *Schema
+	checks     []SchemaCheck
+	scan       []ModuleReport
+}
+
+type memoryReport struct {
+	id          int64
+	reportType  string
+	projectPath string
+	projectID   int64
+	status      string
+	err         string
+	rows        memoryRows
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{}
+}
+
+// report returns a report; the caller holds the lock.
+func (s *MemoryStore) report(reportID int64) (*memoryReport, error) {
+	if reportID < 1 || reportID > int64(len(s.reports)) {
+		return nil, fmt.Errorf("report %d not found", reportID)
+	}
+	return s.reports[reportID-1], nil
+}
+
+// update runs fn on the rows of a report under the lock.
+func (s *MemoryStore) update(reportID int64, fn func(rows *memoryRows)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r, err := s.report(reportID)
+	if err != nil {
+		return err
+	}
+	fn(&r.rows)
+	return nil
+}
+
+// read returns a copy of the rows of a report.
+func (s *MemoryStore) read(reportID int64) (memoryRows, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r, err := s.report(reportID)
+	if err != nil {
+		return memoryRows{}, err
+	}
+	return r.rows, nil
+}
+
+func (s *MemoryStore) CreateReport(reportType, projectPath string) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	root := CanonicalProjectPath(projectPath)
+	var projectID int64
+	for _, p := range s.projects {
+		if p.RootPath == root {
+			projectID = p.ID
+		}
+	}
+	if projectID == 0 {
+		projectID = int64(len(s.projects) + 1)
+		s.projects = append(s.projects, Project{ID: projectID, RootPath: root, Name: filepath.Base(root)})
+	}
+
+	id := int64(len(s.reports) + 1)
+	s.reports = append(s.reports, &memoryReport{
+		id:          id,
+		reportType:  reportType,
+		projectPath: projectPath,
+		projectID:   projectID,
+		status:      ReportRunning,
+	})
+	return id, nil
+}
+
+func (s *MemoryStore) setStatus(reportID int64, status, msg string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r, err := s.report(reportID)
+	if err != nil {
+		return err
+	}
+	r.status, r.err = status, msg
+	return nil
+}
+
+func (s *MemoryStore) CompleteReport(reportID int64) error {
+	return s.setStatus(reportID, ReportComplete, "")
+}
+
+func (s *MemoryStore) FailReport(reportID int64, cause error) error {
+	msg := ""
+	if cause != nil {
+		msg = cause.Error()
+	}
+	return s.setStatus(reportID, ReportFailed, msg)
+}
+
+// latest returns the most recent complete report of a type in a project,
+// or in any project when projectID is 0.
+func (s *MemoryStore) latest(reportType string, projectID int64) int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := len(s.reports) - 1; i >= 0; i-- {
+		r := s.reports[i]
+		if r.reportType != reportType || r.status != ReportComplete {
+			continue
+		}
+		if projectID != 0 && r.projectID != projectID {
+			continue
+		}
+		return r.id
+	}
+	return 0
+}
+
+func (s *MemoryStore) LatestReportID(reportType string) (int64, error) {
+	if id := s.latest(reportType, 0); id != 0 {
+		return id, nil
+	}
+	return 0, noReportError(reportType, "")
+}
+
+func (s *MemoryStore) LatestProjectReportID(reportType, projectPath string) (int64, error) {
+	root := CanonicalProjectPath(projectPath)
+
+	var projectID int64
+	s.mu.Lock()
+	for _, p := range s.projects {
+		if p.RootPath == root {
+			projectID = p.ID
+		}
+	}
+	s.mu.Unlock()
+
+	if projectID != 0 {
+		if id := s.latest(reportType, projectID); id != 0 {
+			return id, nil
+		}
+	}
+	return 0, noReportError(reportType, projectPath)
+}
+
+func (s *MemoryStore) ReportProjectPath(reportID int64) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r, err := s.report(reportID)
+	if err != nil {
+		return "", err
+	}
+	return r.projectPath, nil
+}
+
+func (s *MemoryStore) ListProjects() ([]Project, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	projects := append([]Project(nil), s.projects...)
+	for i := range projects {
+		for _, r := range s.reports {
+			if r.projectID == projects[i].ID {
+				projects[i].Reports++
+			}
+		}
+	}
+	sort.Slice(projects, func(i, j int) bool {
+		return projects[i].RootPath < projects[j].RootPath
+	})
+	return projects, nil
+}
+
+func (s *MemoryStore) NewWriter(reportID int64) ReportWriter {
+	return &memoryReportWriter{store: s, reportID: reportID}
+}
+
+func (s *MemoryStore) SaveMapping(reportID int64, m Mapping) error {
+	return s.update(reportID, func(rows *memoryRows) { rows.mappings = append(rows.mappings, m) })
+}
+
+func (s *MemoryStore) SaveUnresolvedLoad(reportID int64, u UnresolvedLoad) error {
+	return s.update(reportID, func(rows *memoryRows) { rows.unresolved = append(rows.unresolved, u) })
+}
+
+func (s *MemoryStore) SaveColumnMapping(reportID int64, c ColumnMapping) error {
+	return s.update(reportID, func(rows *memoryRows) { rows.columns = append(rows.columns, c) })
+}
+
+func (s *MemoryStore) SaveRoute(reportID int64, route Route) error {
+	return s.update(reportID, func(rows *memoryRows) { rows.routes = append(rows.routes, route) })
+}
+
+func (s *MemoryStore) SaveLineage(reportID int64, row LineageRow) error {
+	return s.update(reportID, func(rows *memoryRows) { rows.lineage = append(rows.lineage, row) })
+}
+
+func (s *MemoryStore) SaveSchema(reportID int64, schema *Schema) error {
+	return s.update(reportID, func(rows *memoryRows) { rows.schema = schema })
+}
+
+func (s *MemoryStore) SaveSchemaCheck(reportID int64, check SchemaCheck) error {
+	return s.update(reportID, func(rows *memoryRows) { rows.checks = append(rows.checks, check) })
+}
+
+func (s *MemoryStore) SaveScan(reportID int64, reports []ModuleReport) error {
+	return s.update(reportID, func(rows *memoryRows) { rows.scan = append(rows.scan, reports...) })
+}
+
+func (s *MemoryStore) LoadMappings(reportID int64) ([]Mapping, error) {
+	rows, err := s.read(reportID)
+	return append([]Mapping(nil), rows.mappings...), err
+}
+
+func (s *MemoryStore) LoadLineage(reportID int64) ([]LineageRow, error) {
+	rows, err := s.read(reportID)
+	return append([]LineageRow(nil), rows.lineage...), err
+}
+
+func (s *MemoryStore) LoadColumnMappings(reportID int64) ([]ColumnMapping, error) {
+	rows, err := s.read(reportID)
+	return append([]ColumnMapping(nil), rows.columns...), err
+}
+
+func (s *MemoryStore) LoadReportSchema(reportID int64) (*Schema, error) {
+	rows, err := s.read(reportID)
+	return rows.schema, err
+}
+
+func (s *MemoryStore) Close() error {
+	return nil
+}
+
+// memoryReportWriter buffers the rows of a report and adds them all at
+// once on Close, so a report never holds half of a run.
+type memoryReportWriter struct {
+	store    *MemoryStore
+	reportID int64
+	rows     memoryRows
+}
+
+func (w *memoryReportWriter) Mapping(m Mapping) {
+	w.rows.mappings = append(w.rows.mappings, m)
+}
+
+func (w *memoryReportWriter) UnresolvedLoad(u UnresolvedLoad) {
+	w.rows.unresolved = append(w.rows.unresolved, u)
+}
+
+func (w *memoryReportWriter) ColumnMapping(c ColumnMapping) {
+	w.rows.columns = append(w.rows.columns, c)
+}
+
+func (w *memoryReportWriter) Route(route Route) {
+	w.rows.routes = append(w.rows.routes, route)
+}
+
+func (w *memoryReportWriter) Lineage(row LineageRow) {
+	w.rows.lineage = append(w.rows.lineage, row)
+}
+
+func (w *memoryReportWriter) Schema(schema *Schema) {
+	w.rows.schema = schema
+}
+
+func (w *memoryReportWriter) SchemaCheck(check SchemaCheck) {
+	w.rows.checks = append(w.rows.checks, check)
+}
+
+func (w *memoryReportWriter) Close() error {
+	return w.store.update(w.reportID, func(rows *memoryRows) {
+		rows.mappings = append(rows.mappings, w.rows.mappings...)
+		rows.unresolved = append(rows.unresolved, w.rows.unresolved...)
+		rows.columns = append(rows.columns, w.rows.columns...)
+		rows.routes = append(rows.routes, w.rows.routes...)
+		rows.lineage = append(rows.lineage, w.rows.lineage...)
+		rows.checks = append(rows.checks, w.rows.checks...)
+		if w.rows.schema != nil {
+			rows.schema = w.rows.schema
+		}
+	})
+}
+
+var _ Store = (*MemoryStore)(nil)
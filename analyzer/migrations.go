@@ -0,0 +1,367 @@
+/*
+Copyright © 2025 Vicky Chhetri <vickychhetri4@gmail.com>
+
+Schema from application/migrations: replays the up() method of every
+migration, in version order, on an empty schema (dbforge calls and raw
+$this->db->query() SQL).
+*/
+
+package analyzer
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Migration is one application/migrations/<version>_<name>.php file.
+type Migration struct {
+	Version uint64
+	Name    string
+	File    string
+}
+
+var migrationFileRegex = regexp.MustCompile(`^(\d+)_(\w+)\.php$`)
+
+// MigrationPath returns the migrations directory configured in
+// application/config/migration.php (application/migrations by default).
+func MigrationPath(projectPath string) string {
+	path := filepath.Join(projectPath, "application", "migrations")
+	cfg, err := ParseConfigFile(filepath.Join(projectPath, "application", "config", "migration.php"), ProjectConstants(projectPath))
+	if err != nil {
+		return path
+	}
+	if p := cfg.String("config", "migration_path"); p != "" {
+		// relative to index.php, the project root
+		p = filepath.FromSlash(p)
+		if !filepath.IsAbs(p) {
+			p = filepath.Join(projectPath, p)
+		}
+		return filepath.Clean(p)
+	}
+	return path
+}
+
+// FindMigrations lists the migration files of a directory sorted by
+// version, the order CI_Migration runs them in.
+func FindMigrations(dir string) ([]Migration, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var migrations []Migration
+	for _, entry := range entries {
+		m := migrationFileRegex.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || m == nil {
+			continue
+		}
+		version, err := strconv.ParseUint(m[1], 10, 64)
+		if err != nil {
+			continue
+		}
+		migrations = append(migrations, Migration{
+			Version: version,
+			Name:    m[2],
+			File:    filepath.Join(dir, entry.Name()),
+		})
+	}
+
+	sort.SliceStable(migrations, func(i, j int) bool {
+		if migrations[i].Version != migrations[j].Version {
+			return migrations[i].Version < migrations[j].Version
+		}
+		return migrations[i].Name < migrations[j].Name
+	})
+
+	return migrations, nil
+}
+
+// LoadMigrationSchema builds the schema the migrations of a project
+// produce when run up to the latest version. dbforge table names get the
+// dbprefix of the active database group.
+func LoadMigrationSchema(projectPath string) (*Schema, error) {
+	dir := MigrationPath(projectPath)
+	migrations, err := FindMigrations(dir)
+	if err != nil {
+		return nil, err
+	}
+	if len(migrations) == 0 {
+		return nil, fmt.Errorf("no migrations found in %s", dir)
+	}
+
+	ctx := DBTableContext(projectPath)
+	schema := NewSchema(dir)
+	consts := ProjectConstants(projectPath)
+
+	for _, m := range migrations {
+		data, err := os.ReadFile(m.File)
+		if err != nil {
+			return nil, err
+		}
+		parsed := ParsePhpCode(string(data))
+		for _, class := range parsed.Classes {
+			for _, method := range class.Methods {
+				if strings.EqualFold(method.Name, "up") {
+					schema.applyMigration(method.body, ctx, consts)
+				}
+			}
+		}
+	}
+
+	return schema, nil
+}
+
+// ------------------------------------------------------------
+// up() REPLAY
+// ------------------------------------------------------------
+
+// forgeState holds what dbforge collects between add_field()/add_key()
+// and create_table().
+type forgeState struct {
+	schema  *Schema
+	ctx     TableContext
+	fields  []SchemaColumn
+	primary []string
+}
+
+// applyMigration runs the statements of an up() body in order. Variable
+// assignments are evaluated so field arrays built in $fields can be passed
+// to dbforge.
+func (s *Schema) applyMigration(body []Token, ctx TableContext, consts map[string]string) {
+	e := &configEval{sig: body, consts: consts, cfg: &PHPConfig{Vars: map[string]any{}}}
+	forge := &forgeState{schema: s, ctx: ctx}
+
+	for i := 0; i < len(body); {
+		end := e.statementEnd(i)
+		if !forge.statement(e, i, end) {
+			e.statement(i)
+		}
+		i = end + 1
+	}
+}
+
+// statement applies the $this->dbforge->x() and $this->db->query() calls of
+// body[from:to] and reports whether there were any.
+func (f *forgeState) statement(e *configEval, from, to int) bool {
+	sig := e.sig
+	found := false
+
+	for i := from; i+4 < to; i++ {
+		if !sig[i].Is(TokenVariable, "$this") || !sig[i+1].IsOp("->") || sig[i+2].Kind != TokenIdent {
+			continue
+		}
+
+		switch sig[i+2].Text {
+		case "dbforge":
+			// calls may be chained: ->add_field(...)->add_key('id', TRUE)
+			j := i + 3
+			for j+2 < to && sig[j].IsOp("->") && sig[j+1].Kind == TokenIdent && sig[j+2].IsOp("(") {
+				f.forgeCall(e, sig[j+1].Text, splitArgs(sig, j+2))
+				j = matchingClose(sig, j+2) + 1
+				found = true
+			}
+			i = j - 1
+
+		case "db":
+			if !sig[i+3].IsOp("->") || i+5 >= to || !sig[i+5].IsOp("(") {
+				continue
+			}
+			if name := sig[i+4].Text; name != "query" && name != "simple_query" {
+				continue
+			}
+			found = true
+			args := splitArgs(sig, i+5)
+			if len(args) == 0 {
+				continue
+			}
+			if v, ok := e.expr(args[0][0], args[0][1]); ok {
+				if sql, isString := v.(string); isString {
+					f.schema.ParseSQL(f.ctx.swapPrefix(sql, f.ctx.Group))
+				}
+			}
+		}
+	}
+
+	return found
+}
+
+func (f *forgeState) forgeCall(e *configEval, name string, args [][2]int) {
+	values := make([]any, len(args))
+	for n, a := range args {
+		values[n], _ = e.expr(a[0], a[1])
+	}
+	arg := func(n int) any {
+		if n < len(values) {
+			return values[n]
+		}
+		return nil
+	}
+	table := func(n int) string {
+		name := configString(arg(n))
+		if name == "" {
+			return ""
+		}
+		return f.ctx.group(f.ctx.Group).Prefix + name
+	}
+
+	switch strings.ToLower(name) {
+	case "add_field":
+		f.addField(arg(0))
+
+	case "add_key":
+		if !configBool(arg(1)) {
+			return
+		}
+		switch key := arg(0).(type) {
+		case string:
+			f.primary = append(f.primary, key)
+		case *PHPArray:
+			for _, k := range key.Keys() {
+				v, _ := key.Get(k)
+				f.primary = append(f.primary, configString(v))
+			}
+		}
+
+	case "create_table":
+		t := table(0)
+		if t != "" && !(configBool(arg(1)) && f.schema.Has(t)) {
+			for _, key := range f.primary {
+				for c := range f.fields {
+					if strings.EqualFold(f.fields[c].Name, key) {
+						f.fields[c].PrimaryKey = true
+						f.fields[c].Nullable = false
+					}
+				}
+			}
+			f.schema.AddTable(SchemaTable{Name: t, Columns: f.fields})
+		}
+		f.fields, f.primary = nil, nil
+
+	case "drop_table":
+		f.schema.DropTable(table(0))
+
+	case "rename_table":
+		if to := table(1); to != "" {
+			f.schema.RenameTable(table(0), to)
+		}
+
+	case "add_column", "modify_column":
+		t, ok := f.schema.Table(table(0))
+		fields, isArr := arg(1).(*PHPArray)
+		if !ok || !isArr {
+			return
+		}
+		modify := strings.EqualFold(name, "modify_column")
+		for _, k := range fields.Keys() {
+			v, _ := fields.Get(k)
+			if def, isString := v.(string); isString {
+				for _, c := range parseColumnDefs(def) {
+					t.SetColumn(c.Name, c)
+				}
+				continue
+			}
+			attrs, isAttrs := v.(*PHPArray)
+			if !isAttrs {
+				continue
+			}
+			if !modify {
+				t.SetColumn(k, forgeColumn(k, attrs, false))
+				continue
+			}
+			c, exists := t.Column(k)
+			if !exists {
+				continue
+			}
+			changed := forgeColumn(k, attrs, false)
+			if a := forgeAttrs(attrs); a["name"] != nil {
+				changed.Name = configString(a["name"])
+			}
+			if changed.Type == "" {
+				changed.Type = c.Type
+			}
+			changed.PrimaryKey = c.PrimaryKey
+			t.SetColumn(k, changed)
+		}
+
+	case "drop_column":
+		if t, ok := f.schema.Table(table(0)); ok {
+			t.DropColumn(configString(arg(1)))
+		}
+	}
+}
+
+// addField collects the columns of an add_field() call: an array of
+// attribute arrays, the string 'id' (an INT(9) auto increment primary key)
+// or a column definition string.
+func (f *forgeState) addField(v any) {
+	switch field := v.(type) {
+	case string:
+		if field == "id" {
+			f.fields = append(f.fields, SchemaColumn{Name: "id", Type: "int(9)"})
+			f.primary = append(f.primary, "id")
+			return
+		}
+		f.fields = append(f.fields, parseColumnDefs(field)...)
+
+	case *PHPArray:
+		for _, k := range field.Keys() {
+			item, _ := field.Get(k)
+			switch attrs := item.(type) {
+			case *PHPArray:
+				f.fields = append(f.fields, forgeColumn(k, attrs, true))
+			case string:
+				f.addField(attrs)
+			}
+		}
+	}
+}
+
+// forgeAttrs returns the attributes of a dbforge field with lowercase
+// keys; CI_DB_forge does not care about their case.
+func forgeAttrs(attrs *PHPArray) map[string]any {
+	m := map[string]any{}
+	for _, k := range attrs.Keys() {
+		m[strings.ToLower(k)], _ = attrs.Get(k)
+	}
+	return m
+}
+
+// forgeColumn converts dbforge field attributes to a column. Like
+// CI_DB_forge, fields without a 'null' attribute are NOT NULL in
+// create_table() and left to the database default (nullable) otherwise.
+func forgeColumn(name string, attrs *PHPArray, createTable bool) SchemaColumn {
+	a := forgeAttrs(attrs)
+
+	typ := strings.ToLower(configString(a["type"]))
+	switch constraint := a["constraint"].(type) {
+	case *PHPArray:
+		var values []string
+		for _, k := range constraint.Keys() {
+			v, _ := constraint.Get(k)
+			values = append(values, "'"+configString(v)+"'")
+		}
+		typ += "(" + strings.Join(values, ",") + ")"
+	case nil:
+	default:
+		if s := configString(constraint); s != "" {
+			typ += "(" + s + ")"
+		}
+	}
+	if typ != "" && configBool(a["unsigned"]) {
+		typ += " unsigned"
+	}
+
+	col := SchemaColumn{Name: normalizeTable(name), Type: typ, Nullable: !createTable}
+	if v, ok := a["null"]; ok {
+		col.Nullable = configBool(v)
+	}
+	if configBool(a["auto_increment"]) {
+		col.Nullable = false
+	}
+	return col
+}
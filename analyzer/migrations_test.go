@@ -0,0 +1,77 @@
+package analyzer
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLoadMigrationSchema(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"application/config/database.php": `<?php
+$active_group = 'default';
+$db['default'] = array(
+	'dbdriver' => 'mysqli',
+	'dbprefix' => 'ci_',
+	'swap_pre' => '{PRE}',
+);`,
+		// 010 runs after 9: versions are numbers, not names
+		"application/migrations/010_alter_pages.php": `<?php
+class Migration_Alter_pages extends CI_Migration {
+	public function up() {
+		$this->dbforge->add_column('pages', array(
+			'slug' => array('type' => 'VARCHAR', 'constraint' => 50),
+		));
+		$this->dbforge->modify_column('pages', array(
+			'title' => array('name' => 'heading', 'type' => 'VARCHAR', 'constraint' => 200),
+		));
+		$this->dbforge->rename_table('pages', 'documents');
+		$this->dbforge->drop_column('users', 'bio');
+	}
+	public function down() {
+		$this->dbforge->drop_table('documents');
+	}
+}`,
+		"application/migrations/9_create_pages.php": `<?php
+class Migration_Create_pages extends CI_Migration {
+	public function up() {
+		$fields = array(
+			'page_id' => array('type' => 'INT', 'constraint' => 11, 'unsigned' => TRUE, 'auto_increment' => TRUE),
+			'title' => array('type' => 'VARCHAR', 'constraint' => '100'),
+		);
+		$this->dbforge->add_field($fields);
+		$this->dbforge->add_key('page_id', TRUE);
+		$this->dbforge->create_table('pages', TRUE);
+		$this->db->query("CREATE TABLE {PRE}logs (id INT NOT NULL, msg TEXT)");
+	}
+}`,
+		"application/migrations/001_create_users.php": `<?php
+class Migration_Create_users extends CI_Migration {
+	public function up() {
+		$this->dbforge->add_field('id');
+		$this->dbforge->add_field(array(
+			'email' => array('type' => 'VARCHAR', 'constraint' => 255),
+			'bio' => array('type' => 'TEXT', 'null' => TRUE),
+		));
+		$this->dbforge->create_table('users');
+		$this->dbforge->create_table('scratch');
+		$this->dbforge->drop_table('scratch');
+	}
+}`,
+	})
+
+	schema, err := LoadMigrationSchema(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		"ci_users: id int(9) pk, email varchar(255)",
+		"ci_logs: id int, msg text null",
+		"ci_documents: page_id int(11) unsigned pk, heading varchar(200) null, slug varchar(50) null",
+	}
+	if got := schemaColumns(schema); !reflect.DeepEqual(got, want) {
+		t.Errorf("schema =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
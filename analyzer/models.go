@@ -0,0 +1,159 @@
+/*
+Copyright © 2025 Vicky Chhetri <vickychhetri4@gmail.com>
+
+Model and library resolution: finds the class behind a
+$this->load->model() or $this->load->library() call the way the HMVC
+loader does (current module, module prefix, application/models or
+application/libraries, then third_party packages).
+*/
+
+package analyzer
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ThirdPartyModule is the pseudo module of the models and libraries
+// shipped in application/third_party/<package>.
+const ThirdPartyModule = "(third_party)"
+
+type loadEntry struct {
+	class  *PHPClass
+	file   string
+	module string
+}
+
+// loadIndex finds the classes of one loadable directory (models or
+// libraries) by name or by load path across the scanned modules, the
+// application and the third_party packages.
+type loadIndex struct {
+	dir    string
+	byName map[string][]loadEntry
+	byPath map[string]loadEntry // lower(module) + "\x00" + lower(path below dir/)
+}
+
+func buildModelIndex(projectPath string, reports []ModuleReport) *loadIndex {
+	return buildLoadIndex(projectPath, reports, "models")
+}
+
+func buildLibraryIndex(projectPath string, reports []ModuleReport) *loadIndex {
+	return buildLoadIndex(projectPath, reports, "libraries")
+}
+
+func buildLoadIndex(projectPath string, reports []ModuleReport, dir string) *loadIndex {
+	idx := &loadIndex{
+		dir:    dir,
+		byName: map[string][]loadEntry{},
+		byPath: map[string]loadEntry{},
+	}
+
+	for r := range reports {
+		for f := range reports[r].Files {
+			file := &reports[r].Files[f]
+			rel, ok := reports[r].relPathBelow(file.FilePathStr, dir)
+			if !ok {
+				continue
+			}
+			for c := range file.Classes {
+				idx.add(&file.Classes[c], file.FilePathStr, rel, reports[r].Module)
+			}
+		}
+	}
+
+	dirs, _ := filepath.Glob(filepath.Join(projectPath, "application", "third_party", "*", dir))
+	for _, dir := range dirs {
+		files, _ := ScanPhpFiles(dir)
+		for _, path := range files {
+			rel, err := filepath.Rel(dir, path)
+			if err != nil {
+				continue
+			}
+			data, err := os.ReadFile(path)
+			if err != nil {
+				continue
+			}
+			parsed := ParsePhpCode(string(data))
+			for c := range parsed.Classes {
+				idx.add(&parsed.Classes[c], path, strings.TrimSuffix(filepath.ToSlash(rel), ".php"), ThirdPartyModule)
+			}
+		}
+	}
+
+	return idx
+}
+
+// add indexes a class of a file whose path below the models (or
+// libraries) directory is rel, e.g. "reports/Sales_model".
+func (idx *loadIndex) add(class *PHPClass, file, rel, module string) {
+	entry := loadEntry{class: class, file: file, module: module}
+
+	name := strings.ToLower(class.ClassName)
+	idx.byName[name] = append(idx.byName[name], entry)
+
+	key := strings.ToLower(module) + "\x00" + strings.ToLower(rel)
+	if _, exists := idx.byPath[key]; !exists {
+		idx.byPath[key] = entry
+	}
+}
+
+// lookup resolves a class by name (or property name) in the caller's
+// module, then the application, then the third_party packages. A class of
+// another module is never picked: which one CI would use depends on what
+// was loaded before.
+func (idx *loadIndex) lookup(name, module string) (loadEntry, bool) {
+	candidates := idx.byName[strings.ToLower(name)]
+	for _, preferred := range []string{module, AppModule, ThirdPartyModule} {
+		for _, c := range candidates {
+			if c.module == preferred {
+				return c, true
+			}
+		}
+	}
+	return loadEntry{}, false
+}
+
+// modulesOf returns the modules that declare a class, sorted.
+func (idx *loadIndex) modulesOf(name string) []string {
+	var modules []string
+	for _, c := range idx.byName[strings.ToLower(name)] {
+		modules = append(modules, c.module)
+	}
+	sort.Strings(modules)
+	return unique(modules)
+}
+
+// resolve finds the class file a load refers to, in the order the HMVC
+// loader searches: the caller's module (path may include a sub-directory),
+// the module named by the first path segment, the application directory and
+// finally the third_party packages.
+func (idx *loadIndex) resolve(load ModelLoad, module string) (loadEntry, bool) {
+	if load.Dynamic {
+		return loadEntry{}, false
+	}
+
+	path := strings.ToLower(strings.TrimSuffix(strings.Trim(load.Path, "/"), ".php"))
+
+	find := func(module, path string) (loadEntry, bool) {
+		entry, ok := idx.byPath[strings.ToLower(module)+"\x00"+path]
+		return entry, ok
+	}
+
+	if entry, ok := find(module, path); ok {
+		return entry, true
+	}
+	if load.Module != "" {
+		if entry, ok := find(load.Module, strings.TrimPrefix(path, strings.ToLower(load.Module)+"/")); ok {
+			return entry, true
+		}
+	}
+	for _, fallback := range []string{AppModule, ThirdPartyModule} {
+		if entry, ok := find(fallback, path); ok {
+			return entry, true
+		}
+	}
+
+	return loadEntry{}, false
+}
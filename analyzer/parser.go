@@ -6,49 +6,508 @@ package analyzer
 
 import (
 	"os"
-	"regexp"
+	"strings"
 )
 
+// PHPFile is the parsed form of a single PHP source file.
+type PHPFile struct {
+	Code      string
+	Tokens    []Token
+	Classes   []PHPClass
+	Functions []PHPMethod // functions declared outside any class
+}
+
+// PHPClass describes a class-like declaration: class, interface or trait.
 type PHPClass struct {
 	ClassName   string
-	Methods     []string
+	Kind        string // class, interface, trait
+	Abstract    bool
+	Final       bool
+	Extends     string   // parent class
+	Implements  []string // implemented interfaces (for an interface: the interfaces it extends)
+	Traits      []string // traits pulled in with "use"
+	Methods     []PHPMethod
+	Properties  []PHPProperty
 	Constructor bool
-	Code        string
+	StartLine   int
+	EndLine     int
+	DocComment  string
+
+	// filled in by ResolveInheritance
+	Ancestors           []string // parent chain, nearest first
+	InheritedMethods    []InheritedMethod
+	InheritedProperties []PHPProperty // nearest declaration first
+}
+
+type PHPMethod struct {
+	Name       string
+	Visibility string // public, protected, private
+	Static     bool
+	Abstract   bool
+	Final      bool
+	Params     []PHPParam
+	ReturnType string
+	StartLine  int
+	EndLine    int
+	DocComment string
+	Routable   bool // set for controller actions reachable through a URL
+
+	body []Token // significant tokens between the braces
+}
+
+type PHPProperty struct {
+	Name       string // without the leading $
+	Visibility string
+	Static     bool
+	Type       string
+	Default    string // default value as written, "" if none
+	Line       int
+}
+
+// StringDefault returns the default value of the property if it is a
+// plain string literal.
+func (p PHPProperty) StringDefault() (string, bool) {
+	sig := SignificantTokens(Tokenize("<?php " + p.Default))
+	if len(sig) != 1 || !sig[0].IsStringLiteral() {
+		return "", false
+	}
+	return sig[0].StringValue(), true
+}
+
+type PHPParam struct {
+	Name     string // without the leading $
+	Type     string
+	Default  string
+	ByRef    bool
+	Variadic bool
+}
+
+// IsRoutable reports whether CodeIgniter's router would dispatch a URL to
+// this method if it lived in a controller: it must be public, concrete and
+// must not start with an underscore.
+func (m PHPMethod) IsRoutable() bool {
+	return m.Visibility == "public" &&
+		!m.Abstract &&
+		!strings.HasPrefix(m.Name, "_") &&
+		!strings.EqualFold(m.Name, "get_instance")
 }
 
-func ParsePhpFiles(path string) (*PHPClass, error) {
+func ParsePhpFiles(path string) (*PHPFile, error) {
 	data, err := os.ReadFile(path)
 
 	if err != nil {
 		return nil, err
 	}
 
-	code := string(data)
+	return ParsePhpCode(string(data)), nil
+}
+
+// ParsePhpCode tokenizes source code and collects every class, interface
+// and trait declared in it, plus any plain functions (helpers).
+func ParsePhpCode(code string) *PHPFile {
+	tokens := Tokenize(code)
+	p := newTokenParser(tokens)
+
+	file := &PHPFile{
+		Code:   code,
+		Tokens: tokens,
+	}
+
+	for i := 0; i < len(p.sig); i++ {
+		// plain functions, e.g. helpers wrapped in if (!function_exists(...))
+		if p.sig[i].IsKeyword("function") {
+			if fn, end, ok := p.functionDecl(i, i, len(p.sig)); ok {
+				file.Functions = append(file.Functions, fn)
+				i = end
+			}
+			continue
+		}
+
+		if !isClassLikeKeyword(p.sig, i) || i+1 >= len(p.sig) || p.sig[i+1].Kind != TokenIdent {
+			continue
+		}
+
+		// a declaration without a body is skipped, the rest of the file
+		// is still parsed
+		class, end := p.classDecl(i)
+		if end < 0 {
+			continue
+		}
+		file.Classes = append(file.Classes, class)
+		i = end
+	}
+
+	return file
+}
+
+// classDecl parses the declaration whose keyword (class, interface, trait)
+// is sig[i]. It returns the index of the closing brace, or -1 if the
+// declaration has no body.
+func (p *tokenParser) classDecl(i int) (PHPClass, int) {
+	class := PHPClass{
+		ClassName: p.sig[i+1].Text,
+		Kind:      strings.ToLower(p.sig[i].Text),
+		StartLine: p.sig[i].Line,
+	}
+
+	first := i
+	for k := i - 1; k >= 0 && (p.sig[k].IsKeyword("abstract") || p.sig[k].IsKeyword("final")); k-- {
+		if p.sig[k].IsKeyword("abstract") {
+			class.Abstract = true
+		} else {
+			class.Final = true
+		}
+		first = k
+	}
+	class.StartLine = p.sig[first].Line
+	class.DocComment = p.docComment(first)
+
+	open := indexOfOp(p.sig, i+2, "{")
+	if open < 0 {
+		return class, -1
+	}
+	for k := i + 2; k < open; k++ {
+		if p.sig[k].IsOp(";") || isClassLikeKeyword(p.sig, k) {
+			return class, -1
+		}
+	}
+
+	// extends / implements clauses
+	var clause string
+	for k := i + 2; k < open; k++ {
+		t := p.sig[k]
+		switch {
+		case t.IsKeyword("extends"), t.IsKeyword("implements"):
+			clause = strings.ToLower(t.Text)
+		case t.Kind == TokenIdent && clause == "extends" && class.Kind == "class":
+			class.Extends = t.Text
+		case t.Kind == TokenIdent && clause != "":
+			class.Implements = append(class.Implements, t.Text)
+		}
+	}
+
+	end := matchingClose(p.sig, open)
+	class.EndLine = p.sig[end].Line
+	class.Traits = p.traitUses(open+1, end, p.sig[open].Depth+1)
+	class.Methods = p.classMethods(open+1, end, p.sig[open].Depth+1)
+	class.Properties = p.classProperties(open+1, end, p.sig[open].Depth+1)
+
+	for _, m := range class.Methods {
+		if m.Name == "__construct" {
+			class.Constructor = true
+		}
+	}
+
+	return class, end
+}
+
+// traitUses returns the traits imported with "use A, B;" in a class body.
+func (p *tokenParser) traitUses(from, to, depth int) []string {
+	var traits []string
+	for i := from; i < to; i++ {
+		if p.sig[i].Depth != depth || !p.sig[i].IsKeyword("use") {
+			continue
+		}
+		for i++; i < to && !p.sig[i].IsOp(";") && !p.sig[i].IsOp("{"); i++ {
+			if p.sig[i].Kind == TokenIdent {
+				traits = append(traits, p.sig[i].Text)
+			}
+		}
+	}
+	return traits
+}
+
+// tokenParser walks the significant tokens of a file while keeping track of
+// where each one sits in the full token stream, so comments (docblocks) and
+// original formatting can still be recovered.
+type tokenParser struct {
+	tokens []Token
+	sig    []Token
+	pos    []int // pos[i] is the index of sig[i] in tokens
+}
+
+func newTokenParser(tokens []Token) *tokenParser {
+	p := &tokenParser{tokens: tokens}
+	for i, t := range tokens {
+		switch t.Kind {
+		case TokenWhitespace, TokenComment, TokenDocComment,
+			TokenInlineHTML, TokenOpenTag, TokenCloseTag:
+			continue
+		}
+		p.sig = append(p.sig, t)
+		p.pos = append(p.pos, i)
+	}
+	return p
+}
+
+// source returns the original text of sig[from:to], comments included.
+func (p *tokenParser) source(from, to int) string {
+	if from >= to {
+		return ""
+	}
+	var b strings.Builder
+	for _, t := range p.tokens[p.pos[from] : p.pos[to-1]+1] {
+		b.WriteString(t.Text)
+	}
+	return strings.TrimSpace(b.String())
+}
 
-	classRegex := regexp.MustCompile(`class\s+(\w+)`)
-	methodRegex := regexp.MustCompile(`function\s+(\w+)`)
+// docComment returns the docblock directly preceding sig[i], if any.
+func (p *tokenParser) docComment(i int) string {
+	for j := p.pos[i] - 1; j >= 0; j-- {
+		switch p.tokens[j].Kind {
+		case TokenWhitespace:
+			continue
+		case TokenDocComment:
+			return p.tokens[j].Text
+		}
+		return ""
+	}
+	return ""
+}
 
-	classMatches := classRegex.FindStringSubmatch(string(data))
-	methodmatches := methodRegex.FindAllStringSubmatch(string(data), -1)
+// isClassLikeKeyword reports whether sig[i] starts a class, interface or
+// trait declaration, as opposed to Foo::class or an anonymous "new class".
+func isClassLikeKeyword(sig []Token, i int) bool {
+	t := sig[i]
+	if !t.IsKeyword("class") && !t.IsKeyword("interface") && !t.IsKeyword("trait") {
+		return false
+	}
+	if i > 0 && (sig[i-1].IsOp("::") || sig[i-1].IsOp("->") || sig[i-1].IsKeyword("new")) {
+		return false
+	}
+	return true
+}
+
+var methodModifiers = map[string]bool{
+	"public":    true,
+	"protected": true,
+	"private":   true,
+	"static":    true,
+	"abstract":  true,
+	"final":     true,
+	"var":       true,
+	"readonly":  true,
+}
 
-	var methods []string
-	hasConstructor := false
-	for _, m := range methodmatches {
-		methods = append(methods, m[1])
-		if m[1] == "__construct" {
-			hasConstructor = true
+// classMethods returns the methods declared directly in the class body
+// sig[from:to]. Closures and functions nested inside methods are ignored.
+func (p *tokenParser) classMethods(from, to, depth int) []PHPMethod {
+	var methods []PHPMethod
+	for i := from; i < to; i++ {
+		if p.sig[i].Depth != depth || !p.sig[i].IsKeyword("function") {
+			continue
 		}
+		method, end, ok := p.functionDecl(i, from, to)
+		if !ok {
+			continue
+		}
+		methods = append(methods, method)
+		i = end
 	}
+	return methods
+}
 
-	if len(classMatches) < 2 {
-		return nil, nil
+// classProperties returns the properties declared directly in the class
+// body sig[from:to], e.g. "protected $_table = 'users';".
+func (p *tokenParser) classProperties(from, to, depth int) []PHPProperty {
+	var props []PHPProperty
+	for i := from; i < to; i++ {
+		t := p.sig[i]
+		if t.Depth != depth || t.Kind != TokenIdent || !methodModifiers[strings.ToLower(t.Text)] {
+			continue
+		}
+		// the return type of "function make(): static { ... }"
+		if i > from && (p.sig[i-1].IsOp(":") || p.sig[i-1].IsOp("?") || p.sig[i-1].IsOp("|")) {
+			continue
+		}
+
+		prop := PHPProperty{Visibility: "public", Line: t.Line}
+		k := i
+		for ; k < to && p.sig[k].Kind == TokenIdent && methodModifiers[strings.ToLower(p.sig[k].Text)]; k++ {
+			switch strings.ToLower(p.sig[k].Text) {
+			case "public", "protected", "private":
+				prop.Visibility = strings.ToLower(p.sig[k].Text)
+			case "static":
+				prop.Static = true
+			}
+		}
+		if k >= to || p.sig[k].IsKeyword("function") || p.sig[k].IsKeyword("const") {
+			i = k
+			continue
+		}
+
+		// the type stays in the class body: a "{" means this was not a
+		// property
+		typeStart := k
+		for k < to && p.sig[k].Depth == depth && p.sig[k].Kind != TokenVariable && !p.sig[k].IsOp(";") && !p.sig[k].IsOp("{") {
+			k++
+		}
+		if k >= to || p.sig[k].Kind != TokenVariable || p.sig[k].Depth != depth {
+			i = k
+			continue
+		}
+		if typeStart < k {
+			prop.Type = p.source(typeStart, k)
+		}
+
+		// $a = 1, $b;
+		for k < to && p.sig[k].Kind == TokenVariable {
+			item := prop
+			item.Name = strings.TrimPrefix(p.sig[k].Text, "$")
+			item.Line = p.sig[k].Line
+			k++
+
+			end := k
+			for ; end < to && !p.sig[end].IsOp(",") && !p.sig[end].IsOp(";") && !p.sig[end].IsOp(")"); end++ {
+				if p.sig[end].IsOp("(") || p.sig[end].IsOp("[") {
+					end = matchingClose(p.sig, end)
+				}
+			}
+			if k < end && p.sig[k].IsOp("=") {
+				item.Default = p.source(k+1, end)
+			}
+			props = append(props, item)
+
+			k = end
+			if k < to && p.sig[k].IsOp(",") {
+				k++
+			}
+		}
+		i = k
 	}
+	return props
+}
 
-	return &PHPClass{
-		ClassName:   classMatches[1],
-		Methods:     methods,
-		Constructor: hasConstructor,
-		Code:        code,
-	}, nil
+// functionDecl parses the named function or method whose "function"
+// keyword is sig[i]. Modifiers are looked up backwards as far as sig[from].
+// It returns the index of the last token of the declaration; ok is false
+// for closures and anything else that is not a named declaration.
+func (p *tokenParser) functionDecl(i, from, to int) (method PHPMethod, end int, ok bool) {
+	j := i + 1
+	if j < to && p.sig[j].IsOp("&") {
+		j++
+	}
+	if j >= to || p.sig[j].Kind != TokenIdent {
+		return method, i, false
+	}
+
+	method = PHPMethod{
+		Name:       p.sig[j].Text,
+		Visibility: "public",
+	}
+
+	// modifiers precede the function keyword
+	first := i
+	for k := i - 1; k >= from && methodModifiers[strings.ToLower(p.sig[k].Text)] && p.sig[k].Kind == TokenIdent; k-- {
+		switch strings.ToLower(p.sig[k].Text) {
+		case "public", "protected", "private":
+			method.Visibility = strings.ToLower(p.sig[k].Text)
+		case "static":
+			method.Static = true
+		case "abstract":
+			method.Abstract = true
+		case "final":
+			method.Final = true
+		}
+		first = k
+	}
+	method.StartLine = p.sig[first].Line
+	method.DocComment = p.docComment(first)
+
+	open := j + 1
+	if open >= to || !p.sig[open].IsOp("(") {
+		return method, i, false
+	}
+	closeParen := matchingClose(p.sig, open)
+	method.Params = p.params(open+1, closeParen)
+
+	// return type, then either a body or ";" (abstract / interface)
+	k := closeParen + 1
+	if k < to && p.sig[k].IsOp(":") {
+		start := k + 1
+		for k = start; k < to && !p.sig[k].IsOp("{") && !p.sig[k].IsOp(";"); k++ {
+		}
+		method.ReturnType = p.source(start, k)
+	}
+
+	end = closeParen
+	if k < to && p.sig[k].IsOp("{") {
+		end = matchingClose(p.sig, k)
+		method.EndLine = p.sig[end].Line
+		method.body = p.sig[k+1 : end]
+	} else if k < to {
+		end = k
+		method.EndLine = p.sig[k].Line
+	}
+
+	return method, end, true
+}
+
+// params parses the parameter list sig[from:to] (without parentheses).
+func (p *tokenParser) params(from, to int) []PHPParam {
+	var params []PHPParam
+
+	start := from
+	for i := from; i <= to; i++ {
+		if i < to {
+			t := p.sig[i]
+			if t.IsOp("(") || t.IsOp("[") {
+				i = matchingClose(p.sig, i)
+				continue
+			}
+			if !t.IsOp(",") {
+				continue
+			}
+		}
+		if param, ok := p.param(start, i); ok {
+			params = append(params, param)
+		}
+		start = i + 1
+	}
+	return params
+}
 
+func (p *tokenParser) param(from, to int) (PHPParam, bool) {
+	var param PHPParam
+	typeStart := -1
+
+	for i := from; i < to; i++ {
+		t := p.sig[i]
+		switch {
+		case t.Kind == TokenVariable:
+			param.Name = strings.TrimPrefix(t.Text, "$")
+			if typeStart >= 0 {
+				end := i
+				for end > typeStart && (p.sig[end-1].IsOp("&") || p.sig[end-1].IsOp("...")) {
+					end--
+				}
+				param.Type = p.source(typeStart, end)
+			}
+			if i+1 < to && p.sig[i+1].IsOp("=") {
+				param.Default = p.source(i+2, to)
+			}
+			return param, true
+		case t.IsOp("&"):
+			param.ByRef = true
+		case t.IsOp("..."):
+			param.Variadic = true
+		case t.Kind == TokenIdent && methodModifiers[strings.ToLower(t.Text)] && typeStart < 0:
+			// PHP 8 constructor promotion (public, private, ...)
+		default:
+			if typeStart < 0 {
+				typeStart = i
+			}
+		}
+	}
+	return param, false
+}
+
+func indexOfOp(tokens []Token, from int, op string) int {
+	for i := from; i < len(tokens); i++ {
+		if tokens[i].IsOp(op) {
+			return i
+		}
+	}
+	return -1
 }
@@ -0,0 +1,43 @@
+package analyzer
+
+import "testing"
+
+func TestParsePhpCodeSkipsClassWithoutBody(t *testing.T) {
+	file := ParsePhpCode(`<?php
+class Broken extends
+;
+class Users_model extends CI_Model {
+	public function find($id) { return $id; }
+}
+`)
+
+	if len(file.Classes) != 1 || file.Classes[0].ClassName != "Users_model" {
+		t.Fatalf("classes = %+v, want only Users_model", file.Classes)
+	}
+	if len(file.Classes[0].Methods) != 1 {
+		t.Errorf("methods = %+v, want find", file.Classes[0].Methods)
+	}
+}
+
+func TestClassPropertiesStaticReturnType(t *testing.T) {
+	file := ParsePhpCode(`<?php
+class Builder {
+	protected $table = 'users';
+
+	public function make(): static {
+		$copy = clone $this;
+		return $copy;
+	}
+
+	public static function create(): ?static { $x = 1; return new static(); }
+}
+`)
+
+	if len(file.Classes) != 1 {
+		t.Fatalf("classes = %d, want 1", len(file.Classes))
+	}
+	props := file.Classes[0].Properties
+	if len(props) != 1 || props[0].Name != "table" || props[0].Default != "'users'" {
+		t.Errorf("properties = %+v, want only $table", props)
+	}
+}
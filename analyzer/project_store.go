@@ -0,0 +1,109 @@
+package analyzer
+
+import (
+	"database/sql"
+	"path/filepath"
+)
+
+// Project is the canonical identity reports are tied to: the absolute,
+// symlink-free root of a CI3 project.
+type Project struct {
+	ID       int64
+	RootPath string
+	Name     string
+	Reports  int
+}
+
+// sqlQueryer is implemented by *sql.DB and *sql.Tx.
+type sqlQueryer interface {
+	Exec(query string, args ...any) (sql.Result, error)
+	QueryRow(query string, args ...any) *sql.Row
+}
+
+// CanonicalProjectPath returns the absolute, cleaned path of a project
+// with symlinks resolved, so "./app", "/srv/app/" and a symlink to it are
+// the same project.
+func CanonicalProjectPath(projectPath string) string {
+	path, err := filepath.Abs(projectPath)
+	if err != nil {
+		return filepath.Clean(projectPath)
+	}
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		path = resolved
+	}
+	return filepath.Clean(path)
+}
+
+// ensureProject returns the ID of a project, registering it first if
+// needed.
+func ensureProject(db sqlQueryer, projectPath string) (int64, error) {
+	root := CanonicalProjectPath(projectPath)
+
+	if _, err := db.Exec(
+		`INSERT OR IGNORE INTO projects (root_path, name) VALUES (?, ?)`,
+		root, filepath.Base(root),
+	); err != nil {
+		return 0, err
+	}
+
+	var id int64
+	err := db.QueryRow(`SELECT id FROM projects WHERE root_path = ?`, root).Scan(&id)
+	return id, err
+}
+
+// backfillProjects ties the reports stored before projects existed to
+// their project.
+func backfillProjects(tx *sql.Tx) error {
+	rows, err := tx.Query(`SELECT DISTINCT project_path FROM reports WHERE project_id IS NULL AND project_path IS NOT NULL`)
+	if err != nil {
+		return err
+	}
+	var paths []string
+	for rows.Next() {
+		var path string
+		if err := rows.Scan(&path); err != nil {
+			rows.Close()
+			return err
+		}
+		paths = append(paths, path)
+	}
+	rows.Close()
+
+	for _, path := range paths {
+		id, err := ensureProject(tx, path)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(
+			`UPDATE reports SET project_id = ? WHERE project_id IS NULL AND project_path = ?`,
+			id, path,
+		); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// listProjects returns the known projects with their number of reports.
+func listProjects(db *sql.DB) ([]Project, error) {
+	rows, err := db.Query(`
+	SELECT p.id, p.root_path, p.name, COUNT(r.id)
+	FROM projects p
+	LEFT JOIN reports r ON r.project_id = p.id
+	GROUP BY p.id
+	ORDER BY p.root_path`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var projects []Project
+	for rows.Next() {
+		var p Project
+		if err := rows.Scan(&p.ID, &p.RootPath, &p.Name, &p.Reports); err != nil {
+			return nil, err
+		}
+		projects = append(projects, p)
+	}
+	return projects, rows.Err()
+}
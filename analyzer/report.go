@@ -8,20 +8,25 @@ import (
 	"fmt"
 	"path/filepath"
 	"strings"
+	"sync"
 )
 
 type FileReport struct {
-	File        string
-	FilePathStr string
-	Folder      string
-	ClassName   string
-	Methods     []string
-	Warnings    []SecurityWarning
+	File         string
+	FilePathStr  string
+	Folder       string
+	IsController bool // below the module's controllers directory
+	Classes      []PHPClass
+	Functions    []PHPMethod
+	Warnings     []SecurityWarning
 }
 
 type ModuleReport struct {
-	Module string
-	Files  []FileReport
+	Module   string
+	Location string // modules location the module was found in
+	Files    []FileReport
+
+	root string // module root the files were scanned from
 }
 
 type SecurityWarning struct {
@@ -33,9 +38,9 @@ type SecurityWarning struct {
 	Rule    string // e.g. SQL_INJECTION_RAW
 }
 
-func BuildReport(module string, modulePath string) (*ModuleReport, error) {
+func BuildReport(module Module) (*ModuleReport, error) {
 	var files []string
-	files, err := ScanPhpFiles(modulePath)
+	files, err := module.PhpFiles()
 
 	if err != nil {
 		fmt.Println("error: ", err)
@@ -43,12 +48,14 @@ func BuildReport(module string, modulePath string) (*ModuleReport, error) {
 	}
 
 	report := &ModuleReport{
-		Module: module,
+		Module:   module.Name,
+		Location: module.Location,
+		root:     module.Path,
 	}
 
 	for _, file := range files {
 		parsed, err := ParsePhpFiles(file)
-		if err != nil || parsed == nil {
+		if err != nil || (len(parsed.Classes) == 0 && len(parsed.Functions) == 0) {
 			continue
 		}
 
@@ -58,17 +65,124 @@ func BuildReport(module string, modulePath string) (*ModuleReport, error) {
 			FileFolder = parts[len(parts)-2]
 		}
 
-		warnings := AnalyzeSecurity(parsed.Code, file)
+		warnings := AnalyzeSecurityTokens(parsed.Tokens, file)
+
+		controller := report.inDir(file, "controllers")
+		if controller {
+			for c := range parsed.Classes {
+				methods := parsed.Classes[c].Methods
+				for i := range methods {
+					methods[i].Routable = parsed.Classes[c].Kind == "class" &&
+						!parsed.Classes[c].Abstract &&
+						methods[i].IsRoutable()
+				}
+			}
+		}
 
 		report.Files = append(report.Files, FileReport{
-			File:        filepath.Base(file),
-			FilePathStr: file,
-			Folder:      FileFolder,
-			ClassName:   parsed.ClassName,
-			Methods:     parsed.Methods,
-			Warnings:    warnings,
+			File:         filepath.Base(file),
+			FilePathStr:  file,
+			Folder:       FileFolder,
+			IsController: controller,
+			Classes:      parsed.Classes,
+			Functions:    parsed.Functions,
+			Warnings:     warnings,
 		})
 	}
 
 	return report, nil
 }
+
+// BuildReports runs BuildReport for every module concurrently, skipping
+// modules that fail to scan. Reports keep the order of modules.
+func BuildReports(modules []Module) []ModuleReport {
+	results := make([]*ModuleReport, len(modules))
+
+	var wg sync.WaitGroup
+	for i, m := range modules {
+		wg.Add(1)
+		go func(i int, module Module) {
+			defer wg.Done()
+			report, err := BuildReport(module)
+			if err == nil {
+				results[i] = report
+			}
+		}(i, m)
+	}
+	wg.Wait()
+
+	var reports []ModuleReport
+	for _, r := range results {
+		if r != nil {
+			reports = append(reports, *r)
+		}
+	}
+	return reports
+}
+
+// ClassNames returns the names of every declaration in the file.
+func (f FileReport) ClassNames() []string {
+	var names []string
+	for _, c := range f.Classes {
+		names = append(names, c.ClassName)
+	}
+	return names
+}
+
+// MethodCount returns the number of methods across all declarations,
+// plus the file's plain functions.
+func (f FileReport) MethodCount() int {
+	count := len(f.Functions)
+	for _, c := range f.Classes {
+		count += len(c.Methods)
+	}
+	return count
+}
+
+// moduleParts splits the path of a file below the module root, e.g.
+// [controllers admin Users.php], so the directories the project itself
+// lives in (/home/x/models/app) are never mistaken for module directories.
+// Without a root (reports not built by BuildReport) the whole path is
+// returned.
+func (r ModuleReport) moduleParts(path string) []string {
+	if r.root != "" {
+		if rel, err := filepath.Rel(r.root, path); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return strings.Split(filepath.ToSlash(rel), "/")
+		}
+	}
+	return strings.Split(filepath.ToSlash(path), "/")
+}
+
+// dirIndex returns the position of a directory in the parts of a path: the
+// first part below the module root, or the nearest one without a root.
+func (r ModuleReport) dirIndex(parts []string, dir string) int {
+	if r.root != "" {
+		if len(parts) > 1 && parts[0] == dir {
+			return 0
+		}
+		return -1
+	}
+	for i := len(parts) - 2; i >= 0; i-- {
+		if parts[i] == dir {
+			return i
+		}
+	}
+	return -1
+}
+
+// inDir reports whether a file lives in the module directory of that name
+// (including sub-directories such as controllers/admin).
+func (r ModuleReport) inDir(path, dir string) bool {
+	return r.dirIndex(r.moduleParts(path), dir) >= 0
+}
+
+// relPathBelow returns the file path below a module directory without the
+// .php extension, e.g. "reports/Sales_model" for models.
+func (r ModuleReport) relPathBelow(path, dir string) (string, bool) {
+	parts := r.moduleParts(path)
+	i := r.dirIndex(parts, dir)
+	if i < 0 {
+		return "", false
+	}
+	return strings.TrimSuffix(strings.Join(parts[i+1:], "/"), ".php"), true
+}
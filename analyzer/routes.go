@@ -0,0 +1,436 @@
+/*
+Copyright © 2025 Vicky Chhetri <vickychhetri4@gmail.com>
+
+CI3 URL routing: application/config/routes.php, module level
+config/routes.php (HMVC) and the implicit /module/controller/method
+URL scheme.
+*/
+
+package analyzer
+
+import (
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+type Route struct {
+	Pattern    string // URL pattern, e.g. admin/users/edit/(:num)
+	Verb       string // HTTP verb restriction, "" for any
+	Target     string // route target as written in routes.php ("" for implicit routes)
+	Module     string
+	Controller string // controller path below controllers/, e.g. users or admin/users
+	Class      string
+	Method     string
+	File       string // controller file
+	Explicit   bool
+	Source     string // routes.php the route was defined in
+}
+
+// Resolved reports whether the route points to a known controller.
+func (r Route) Resolved() bool {
+	return r.File != ""
+}
+
+type RouteTable struct {
+	DefaultController  string
+	Override404        string
+	TranslateURIDashes bool
+	Routes             []Route
+
+	controllers  []routeController
+	modules      map[string]bool
+	moduleRoutes map[string]string // module config/routes.php → lowercase module
+}
+
+type routeController struct {
+	module  string
+	path    string // lowercase path below controllers/ without .php
+	class   string
+	file    string
+	methods map[string]PHPMethod // lowercase name → routable method
+	order   []string
+}
+
+// special keys of $route that are settings rather than routes
+var routeSettings = map[string]bool{
+	"default_controller":   true,
+	"404_override":         true,
+	"translate_uri_dashes": true,
+}
+
+// BuildRouteTable combines the explicit routes of the project (and of each
+// HMVC module) with the implicit controller/method URLs CI3 serves. Run
+// ResolveInheritance on the reports first so inherited actions are included.
+func BuildRouteTable(projectPath string, reports []ModuleReport) *RouteTable {
+	table := &RouteTable{modules: map[string]bool{}, moduleRoutes: map[string]string{}}
+	table.indexControllers(reports)
+
+	consts := ProjectConstants(projectPath)
+
+	// application/config/routes.php
+	appRoutes := filepath.Join(projectPath, "application", "config", "routes.php")
+	if cfg, err := ParseConfigFile(appRoutes, consts); err == nil {
+		table.DefaultController = cfg.String("route", "default_controller")
+		table.Override404 = cfg.String("route", "404_override")
+		table.TranslateURIDashes = cfg.Bool("route", "translate_uri_dashes")
+		table.addExplicit(cfg.Array("route"), appRoutes, "")
+	}
+
+	// module level config/routes.php
+	for _, r := range reports {
+		if r.Module == AppModule {
+			continue
+		}
+		for _, location := range ModuleLocations(projectPath) {
+			path := filepath.Join(location, r.Module, "config", "routes.php")
+			cfg, err := ParseConfigFile(path, consts)
+			if err != nil {
+				continue
+			}
+			table.addExplicit(cfg.Array("route"), path, r.Module)
+			table.moduleRoutes[path] = strings.ToLower(r.Module)
+			break
+		}
+	}
+
+	if table.DefaultController != "" {
+		route := table.resolveTarget(Route{
+			Pattern:  "/",
+			Target:   table.DefaultController,
+			Explicit: true,
+			Source:   appRoutes,
+		})
+		if route.Method == "" {
+			route.Method = "index"
+		}
+		table.Routes = append([]Route{route}, table.Routes...)
+	}
+
+	if table.Override404 != "" {
+		table.Routes = append(table.Routes, table.resolveTarget(Route{
+			Pattern:  "(404)",
+			Target:   table.Override404,
+			Explicit: true,
+			Source:   appRoutes,
+		}))
+	}
+
+	table.addImplicit()
+
+	return table
+}
+
+func (t *RouteTable) indexControllers(reports []ModuleReport) {
+	for _, r := range reports {
+		if r.Module != AppModule {
+			t.modules[strings.ToLower(r.Module)] = true
+		}
+		for _, f := range r.Files {
+			rel, ok := r.relPathBelow(f.FilePathStr, "controllers")
+			if !ok {
+				continue
+			}
+			for _, c := range f.Classes {
+				if c.Kind != "class" || c.Abstract {
+					continue
+				}
+				rc := routeController{
+					module:  r.Module,
+					path:    strings.ToLower(rel),
+					class:   c.ClassName,
+					file:    f.FilePathStr,
+					methods: map[string]PHPMethod{},
+				}
+				for _, m := range c.Methods {
+					if m.Routable {
+						rc.add(m)
+					}
+				}
+				for _, m := range c.InheritedMethods {
+					if m.Routable {
+						rc.add(m.PHPMethod)
+					}
+				}
+				t.controllers = append(t.controllers, rc)
+			}
+		}
+	}
+}
+
+func (rc *routeController) add(m PHPMethod) {
+	key := strings.ToLower(m.Name)
+	if _, ok := rc.methods[key]; ok {
+		return
+	}
+	rc.methods[key] = m
+	rc.order = append(rc.order, key)
+}
+
+func (t *RouteTable) addExplicit(routes *PHPArray, source, module string) {
+	if routes == nil {
+		return
+	}
+	for _, pattern := range routes.Keys() {
+		if routeSettings[pattern] {
+			continue
+		}
+		v, _ := routes.Get(pattern)
+
+		// $route['pattern']['GET'] = 'target';
+		if verbs, ok := v.(*PHPArray); ok {
+			for _, verb := range verbs.Keys() {
+				target, _ := verbs.Get(verb)
+				t.Routes = append(t.Routes, t.resolveTarget(Route{
+					Pattern:  pattern,
+					Verb:     strings.ToUpper(verb),
+					Target:   configString(target),
+					Module:   module,
+					Explicit: true,
+					Source:   source,
+				}))
+			}
+			continue
+		}
+
+		t.Routes = append(t.Routes, t.resolveTarget(Route{
+			Pattern:  pattern,
+			Target:   configString(v),
+			Module:   module,
+			Explicit: true,
+			Source:   source,
+		}))
+	}
+}
+
+// addImplicit adds a route for every routable controller method that is
+// reachable through CI3's /module/controller/method URL scheme.
+func (t *RouteTable) addImplicit() {
+	sort.SliceStable(t.controllers, func(i, j int) bool {
+		if t.controllers[i].module != t.controllers[j].module {
+			return t.controllers[i].module < t.controllers[j].module
+		}
+		return t.controllers[i].path < t.controllers[j].path
+	})
+
+	for _, rc := range t.controllers {
+		base := rc.path
+		if rc.module != AppModule {
+			if rc.path == strings.ToLower(rc.module) {
+				base = rc.path
+			} else {
+				base = strings.ToLower(rc.module) + "/" + rc.path
+			}
+		}
+
+		for _, key := range rc.order {
+			m := rc.methods[key]
+			// the second segment is always the method, so only an index
+			// without parameters is served by the bare controller URL
+			pattern := base + "/" + m.Name
+			if key == "index" && len(m.Params) == 0 {
+				pattern = base
+			}
+			for _, p := range m.Params {
+				if p.Default != "" || p.Variadic {
+					pattern += "[/{" + p.Name + "}]"
+				} else {
+					pattern += "/{" + p.Name + "}"
+				}
+			}
+
+			t.Routes = append(t.Routes, Route{
+				Pattern:    pattern,
+				Module:     rc.module,
+				Controller: rc.path,
+				Class:      rc.class,
+				Method:     m.Name,
+				File:       rc.file,
+			})
+		}
+	}
+}
+
+// resolveTarget fills in the controller details of an explicit route.
+func (t *RouteTable) resolveTarget(route Route) Route {
+	segments := splitSegments(route.Target)
+	rc, method := t.locate(segments)
+	if rc == nil {
+		return route
+	}
+
+	route.Module = rc.module
+	route.Controller = rc.path
+	route.Class = rc.class
+	route.File = rc.file
+	route.Method = method
+	if m, ok := rc.methods[strings.ToLower(method)]; ok {
+		route.Method = m.Name
+	}
+	return route
+}
+
+func splitSegments(uri string) []string {
+	var segments []string
+	for _, s := range strings.Split(strings.Trim(uri, "/"), "/") {
+		if s != "" {
+			segments = append(segments, s)
+		}
+	}
+	return segments
+}
+
+// locate mirrors the HMVC router: a leading module segment selects the
+// module (controller named after the second segment, or after the module
+// itself), otherwise application/controllers and its sub-directories are
+// searched. It returns the controller and the method segment.
+func (t *RouteTable) locate(segments []string) (*routeController, string) {
+	if len(segments) == 0 {
+		return nil, ""
+	}
+
+	seg := make([]string, len(segments))
+	for i, s := range segments {
+		seg[i] = strings.ToLower(s)
+		if t.TranslateURIDashes && i < 3 {
+			seg[i] = strings.ReplaceAll(seg[i], "-", "_")
+		}
+	}
+
+	methodAt := func(i int) string {
+		if i < len(segments) {
+			if t.TranslateURIDashes {
+				return strings.ReplaceAll(segments[i], "-", "_")
+			}
+			return segments[i]
+		}
+		return "index"
+	}
+
+	if t.modules[seg[0]] {
+		module := seg[0]
+		if len(seg) > 1 {
+			// module/sub-directory/controller
+			if len(seg) > 2 {
+				if rc := t.find(module, seg[1]+"/"+seg[2]); rc != nil {
+					return rc, methodAt(3)
+				}
+			}
+			if rc := t.find(module, seg[1]); rc != nil {
+				return rc, methodAt(2)
+			}
+		}
+		if rc := t.find(module, module); rc != nil {
+			return rc, methodAt(1)
+		}
+	}
+
+	if len(seg) > 1 {
+		if rc := t.find(AppModule, seg[0]+"/"+seg[1]); rc != nil {
+			return rc, methodAt(2)
+		}
+	}
+	if rc := t.find(AppModule, seg[0]); rc != nil {
+		return rc, methodAt(1)
+	}
+
+	return nil, ""
+}
+
+func (t *RouteTable) find(module, path string) *routeController {
+	for i := range t.controllers {
+		rc := &t.controllers[i]
+		if strings.EqualFold(rc.module, module) && rc.path == path {
+			return rc
+		}
+	}
+	return nil
+}
+
+// like CI, only the wildcard is replaced: "(:num)" stays a capturing group
+var routeWildcards = strings.NewReplacer(":any", "[^/]+", ":num", "[0-9]+")
+
+// back-references of a route target: $1, $2, ...
+var routeBackrefRegex = regexp.MustCompile(`\$(\d+)`)
+
+// match applies an explicit route to a URI. Back-references in the target
+// follow preg_replace: "catalog/$1_$2" uses groups 1 and 2.
+func (t *RouteTable) match(r Route, uri, verb string) (Route, bool) {
+	if r.Verb != "" && !strings.EqualFold(r.Verb, verb) {
+		return Route{}, false
+	}
+	re, err := regexp.Compile("^" + routeWildcards.Replace(r.Pattern) + "$")
+	if err != nil {
+		return Route{}, false
+	}
+	match := re.FindStringSubmatchIndex(uri)
+	if match == nil {
+		return Route{}, false
+	}
+
+	target := r.Target
+	if strings.Contains(target, "$") {
+		template := routeBackrefRegex.ReplaceAllString(target, "$${$1}")
+		target = string(re.ExpandString(nil, template, uri, match))
+	}
+	return t.resolveTarget(Route{
+		Pattern:  r.Pattern,
+		Verb:     r.Verb,
+		Target:   target,
+		Explicit: true,
+		Source:   r.Source,
+	}), true
+}
+
+// Resolve finds the controller method serving a URI the way CI3 does:
+// explicit routes are tried in order (honouring verb restrictions), then
+// the URI is mapped onto controller/method segments. Like the HMVC
+// router, the routes of a module's config/routes.php only apply to URIs
+// starting with the module name, and before the application routes.
+func (t *RouteTable) Resolve(uri, verb string) (Route, bool) {
+	uri = strings.Trim(uri, "/")
+	if uri == "" {
+		for _, r := range t.Routes {
+			if r.Pattern == "/" {
+				return r, r.Resolved()
+			}
+		}
+	}
+
+	first := ""
+	if seg := splitSegments(uri); len(seg) > 0 {
+		first = strings.ToLower(seg[0])
+	}
+	for _, moduleRoutes := range []bool{true, false} {
+		for _, r := range t.Routes {
+			if !r.Explicit || r.Pattern == "/" || r.Pattern == "(404)" {
+				continue
+			}
+			module, ok := t.moduleRoutes[r.Source]
+			if ok != moduleRoutes || (ok && module != first) {
+				continue
+			}
+			if route, ok := t.match(r, uri, verb); ok {
+				return route, route.Resolved()
+			}
+		}
+	}
+
+	rc, method := t.locate(splitSegments(uri))
+	if rc == nil {
+		return Route{Pattern: uri}, false
+	}
+	m, ok := rc.methods[strings.ToLower(method)]
+	if !ok {
+		return Route{Pattern: uri, Module: rc.module, Controller: rc.path, Class: rc.class, File: rc.file, Method: method}, false
+	}
+	return Route{
+		Pattern:    uri,
+		Module:     rc.module,
+		Controller: rc.path,
+		Class:      rc.class,
+		Method:     m.Name,
+		File:       rc.file,
+	}, true
+}
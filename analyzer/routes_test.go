@@ -0,0 +1,138 @@
+package analyzer
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+)
+
+// writeFiles creates files (path relative to dir → contents) below dir.
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, code := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(code), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func testRouteTable(t *testing.T) *RouteTable {
+	t.Helper()
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"application/config/config.php": `<?php $config['base_url'] = '';`,
+		"application/config/routes.php": `<?php
+$route['default_controller'] = 'welcome';
+$route['translate_uri_dashes'] = TRUE;
+$route['people/(:num)'] = 'users/users/index/$1';
+$route['posts/(:any)/(:num)'] = 'posts/show/$2';
+$route['do/(:any)'] = 'posts/$1_action';
+$route['login']['POST'] = 'auth/login';
+`,
+		"application/controllers/Welcome.php": `<?php
+class Welcome extends CI_Controller {
+	public function index() {}
+}`,
+		"application/controllers/Posts.php": `<?php
+class Posts extends CI_Controller {
+	public function index() {}
+	public function show($id) {}
+	public function list_action() {}
+}`,
+		"application/controllers/Auth.php": `<?php
+class Auth extends CI_Controller {
+	public function login() {}
+}`,
+		"application/controllers/User_admin.php": `<?php
+class User_admin extends CI_Controller {
+	public function show_all($page = 1) {}
+}`,
+		"application/modules/users/config/routes.php": `<?php
+$route['users/members'] = 'users/listing';
+$route['members'] = 'users/listing';
+`,
+		"application/modules/users/controllers/Users.php": `<?php
+class Users extends MX_Controller {
+	public function index($page) {}
+	public function listing() {}
+}`,
+	})
+
+	modules, err := ScanModules(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reports := BuildReports(modules)
+	ResolveInheritance(dir, reports)
+	return BuildRouteTable(dir, reports)
+}
+
+func TestRouteTableResolve(t *testing.T) {
+	table := testRouteTable(t)
+
+	tests := []struct {
+		uri, verb     string
+		class, method string // "" when the URI is not served
+	}{
+		{"/", "GET", "Welcome", "index"},
+		{"people/42", "GET", "Users", "index"},
+		{"people/abc", "GET", "", ""},
+		{"posts/hello/7", "GET", "Posts", "show"},
+		{"do/list", "GET", "Posts", "list_action"},
+		{"login", "POST", "Auth", "login"},
+		{"login", "GET", "", ""},
+		{"user-admin/show-all", "GET", "User_admin", "show_all"},
+		{"user-admin/show-all/2", "GET", "User_admin", "show_all"},
+		{"users/members", "GET", "Users", "listing"},
+		{"members", "GET", "", ""},
+		{"users/index/5", "GET", "Users", "index"},
+		{"users/5", "GET", "", ""},
+		{"posts", "GET", "Posts", "index"},
+	}
+	for _, tt := range tests {
+		route, ok := table.Resolve(tt.uri, tt.verb)
+		if tt.class == "" {
+			if ok {
+				t.Errorf("Resolve(%q, %s) = %s::%s, want no route", tt.uri, tt.verb, route.Class, route.Method)
+			}
+			continue
+		}
+		if !ok || route.Class != tt.class || route.Method != tt.method {
+			t.Errorf("Resolve(%q, %s) = %s::%s (%v), want %s::%s", tt.uri, tt.verb, route.Class, route.Method, ok, tt.class, tt.method)
+		}
+	}
+}
+
+// every implicit route must resolve to its own method
+func TestImplicitRoutesResolve(t *testing.T) {
+	table := testRouteTable(t)
+
+	optional := regexp.MustCompile(`\[/\{[^}]*\}\]`)
+	param := regexp.MustCompile(`\{[^}]*\}`)
+
+	found := false
+	for _, r := range table.Routes {
+		if r.Explicit {
+			continue
+		}
+		if r.Class == "Users" && r.Method == "index" {
+			found = true
+			if r.Pattern != "users/index/{page}" {
+				t.Errorf("index with a parameter: pattern = %q, want users/index/{page}", r.Pattern)
+			}
+		}
+		uri := param.ReplaceAllString(optional.ReplaceAllString(r.Pattern, "/1"), "1")
+		got, ok := table.Resolve(uri, "GET")
+		if !ok || got.Class != r.Class || got.Method != r.Method {
+			t.Errorf("route %q: Resolve(%q) = %s::%s (%v), want %s::%s", r.Pattern, uri, got.Class, got.Method, ok, r.Class, r.Method)
+		}
+	}
+	if !found {
+		t.Error("no implicit route for Users::index")
+	}
+}
@@ -0,0 +1,159 @@
+package analyzer
+
+import (
+	"database/sql"
+	"strings"
+)
+
+// saveScan stores the modules, files, classes, methods and security
+// warnings of a scan under a report, in a single transaction.
+func saveScan(db *sql.DB, reportID int64, reports []ModuleReport) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, r := range reports {
+		if _, err := tx.Exec(`
+		INSERT INTO scan_modules (report_id, module, location, file_count)
+		VALUES (?, ?, ?, ?)`,
+			reportID,
+			r.Module,
+			r.Location,
+			len(r.Files),
+		); err != nil {
+			return err
+		}
+
+		for _, f := range r.Files {
+			if err := saveScanFile(tx, reportID, r.Module, f); err != nil {
+				return err
+			}
+		}
+	}
+
+	return tx.Commit()
+}
+
+func saveScanFile(tx *sql.Tx, reportID int64, module string, f FileReport) error {
+	if _, err := tx.Exec(`
+	INSERT INTO scan_files
+	(report_id, module, file, file_path, folder, class_count, method_count, warning_count)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		reportID,
+		module,
+		f.File,
+		f.FilePathStr,
+		f.Folder,
+		len(f.Classes),
+		f.MethodCount(),
+		len(f.Warnings),
+	); err != nil {
+		return err
+	}
+
+	for _, c := range f.Classes {
+		if _, err := tx.Exec(`
+		INSERT INTO scan_classes
+		(report_id, module, file_path, class_name, kind, abstract, final, extends, implements, traits, ancestors, start_line, end_line)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			reportID,
+			module,
+			f.FilePathStr,
+			c.ClassName,
+			c.Kind,
+			c.Abstract,
+			c.Final,
+			c.Extends,
+			strings.Join(c.Implements, ", "),
+			strings.Join(c.Traits, ", "),
+			strings.Join(c.Ancestors, ", "),
+			c.StartLine,
+			c.EndLine,
+		); err != nil {
+			return err
+		}
+
+		for _, m := range c.Methods {
+			if err := saveScanMethod(tx, reportID, module, f.FilePathStr, c.ClassName, m); err != nil {
+				return err
+			}
+		}
+	}
+
+	// plain functions (helpers) have no class
+	for _, fn := range f.Functions {
+		if err := saveScanMethod(tx, reportID, module, f.FilePathStr, "", fn); err != nil {
+			return err
+		}
+	}
+
+	for _, w := range f.Warnings {
+		if _, err := tx.Exec(`
+		INSERT INTO security_warnings
+		(report_id, module, file_path, line, level, rule, message, snippet)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+			reportID,
+			module,
+			w.File,
+			w.Line,
+			w.Level,
+			w.Rule,
+			w.Message,
+			w.Snippet,
+		); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func saveScanMethod(tx *sql.Tx, reportID int64, module, file, class string, m PHPMethod) error {
+	_, err := tx.Exec(`
+	INSERT INTO scan_methods
+	(report_id, module, file_path, class_name, method_name, visibility, static, abstract, final, params, return_type, routable, start_line, end_line)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		reportID,
+		module,
+		file,
+		class,
+		m.Name,
+		m.Visibility,
+		m.Static,
+		m.Abstract,
+		m.Final,
+		paramList(m.Params),
+		m.ReturnType,
+		m.Routable,
+		m.StartLine,
+		m.EndLine,
+	)
+
+	return err
+}
+
+// paramList formats parameters the way they are declared:
+// "int $id, array &$rows = array()".
+func paramList(params []PHPParam) string {
+	var parts []string
+	for _, p := range params {
+		var b strings.Builder
+		if p.Type != "" {
+			b.WriteString(p.Type + " ")
+		}
+		if p.ByRef {
+			b.WriteString("&")
+		}
+		if p.Variadic {
+			b.WriteString("...")
+		}
+		b.WriteString("$" + p.Name)
+		if p.Default != "" {
+			b.WriteString(" = " + p.Default)
+		}
+		parts = append(parts, b.String())
+	}
+	return strings.Join(parts, ", ")
+}
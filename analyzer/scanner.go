@@ -5,29 +5,157 @@ Copyright © 2025 Vicky Chhetri <vickychhetri4@gmail.com>
 package analyzer
 
 import (
+	"fmt"
 	"io/fs"
 	"os"
 	"path/filepath"
 )
 
-func ScanModules(basePath string) ([]string, error) {
-	modulePath := filepath.Join(basePath, "application", "modules")
+// AppModule is the pseudo-module holding the standard (non-HMVC) CI3
+// directories: application/controllers, models, libraries, helpers, core.
+const AppModule = "(app)"
 
-	entries, err := os.ReadDir(modulePath)
-	if err != nil {
-		return nil, err
+// appDirs are the standard CI3 directories scanned for the AppModule.
+var appDirs = []string{"controllers", "models", "libraries", "helpers", "core"}
+
+type Module struct {
+	Name     string
+	Path     string   // module root (application/ for the AppModule)
+	Dirs     []string // directories scanned for PHP files
+	Location string   // modules location the module was found in, relative to the project
+}
+
+// ControllersPath returns the module's controllers directory.
+func (m Module) ControllersPath() string {
+	return filepath.Join(m.Path, "controllers")
+}
+
+// ModelsPath returns the module's models directory.
+func (m Module) ModelsPath() string {
+	return filepath.Join(m.Path, "models")
+}
+
+// ScanModules discovers the HMVC modules in every configured modules
+// location (see ModuleLocations) and the standard CI3 application
+// directories, which are reported as AppModule.
+func ScanModules(basePath string) ([]Module, error) {
+	var modules []Module
+
+	appPath := filepath.Join(basePath, "application")
+	app := Module{Name: AppModule, Path: appPath, Location: "application"}
+	for _, dir := range appDirs {
+		dirPath := filepath.Join(appPath, dir)
+		if info, err := os.Stat(dirPath); err == nil && info.IsDir() {
+			app.Dirs = append(app.Dirs, dirPath)
+		}
+	}
+	if len(app.Dirs) > 0 {
+		modules = append(modules, app)
 	}
 
-	var modules []string
-	for _, e := range entries {
-		if e.IsDir() {
-			modules = append(modules, e.Name())
+	seen := map[string]bool{}
+	for _, location := range ModuleLocations(basePath) {
+		entries, err := os.ReadDir(location)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, err
+		}
+
+		rel := location
+		if r, err := relToProject(basePath, location); err == nil {
+			rel = filepath.ToSlash(r)
+		}
+
+		for _, e := range entries {
+			// like the HMVC loader, the first location holding a module wins
+			if !e.IsDir() || seen[e.Name()] {
+				continue
+			}
+			seen[e.Name()] = true
+
+			path := filepath.Join(location, e.Name())
+			modules = append(modules, Module{
+				Name:     e.Name(),
+				Path:     path,
+				Dirs:     []string{path},
+				Location: rel,
+			})
 		}
 	}
 
+	if len(modules) == 0 {
+		return nil, fmt.Errorf("no CodeIgniter 3 application found in %s", basePath)
+	}
+
 	return modules, nil
 }
 
+// ModuleLocations returns the module roots configured with
+// $config['modules_locations'] in application/config/config.php, in order.
+// Without that setting HMVC falls back to application/modules.
+func ModuleLocations(basePath string) []string {
+	defaultLocation := filepath.Join(basePath, "application", "modules")
+
+	cfg, err := ParseConfigFile(
+		filepath.Join(basePath, "application", "config", "config.php"),
+		ProjectConstants(basePath),
+	)
+	if err != nil {
+		return []string{defaultLocation}
+	}
+
+	locations := cfg.Array("config", "modules_locations")
+	if locations == nil || locations.Len() == 0 {
+		return []string{defaultLocation}
+	}
+
+	var result []string
+	for _, key := range locations.Keys() {
+		path := filepath.FromSlash(key)
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(basePath, path)
+		} else if rel, err := relToProject(basePath, path); err == nil {
+			// keep locations in the same (possibly relative) form as basePath
+			path = filepath.Join(basePath, rel)
+		}
+		result = append(result, filepath.Clean(path))
+	}
+	return result
+}
+
+// relToProject returns path relative to the project root, resolving both
+// to absolute paths first.
+func relToProject(basePath, path string) (string, error) {
+	absBase, err := filepath.Abs(basePath)
+	if err != nil {
+		return "", err
+	}
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	rel, err := filepath.Rel(absBase, absPath)
+	if err != nil {
+		return "", err
+	}
+	return filepath.ToSlash(rel), nil
+}
+
+// PhpFiles returns every PHP file in the module's directories.
+func (m Module) PhpFiles() ([]string, error) {
+	var files []string
+	for _, dir := range m.Dirs {
+		found, err := ScanPhpFiles(dir)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, found...)
+	}
+	return files, nil
+}
+
 func ScanPhpFiles(modulePath string) ([]string, error) {
 	var files []string
 
@@ -0,0 +1,577 @@
+/*
+Copyright © 2025 Vicky Chhetri <vickychhetri4@gmail.com>
+
+Database schema: CREATE TABLE statements from a SQL dump, used to check
+the tables found in code against the real ones.
+*/
+
+package analyzer
+
+import (
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+type SchemaColumn struct {
+	Name       string
+	Type       string
+	Nullable   bool
+	PrimaryKey bool
+}
+
+type SchemaTable struct {
+	Name    string
+	Columns []SchemaColumn
+}
+
+type Schema struct {
+	Source string // file (or directory) the schema was read from
+	Tables []SchemaTable
+
+	index map[string]int // normalized table name → position in Tables
+}
+
+func NewSchema(source string) *Schema {
+	return &Schema{Source: source, index: map[string]int{}}
+}
+
+// Table returns a table by name (case-insensitive, quoting ignored).
+func (s *Schema) Table(name string) (*SchemaTable, bool) {
+	i, ok := s.index[normalizeTable(name)]
+	if !ok {
+		return nil, false
+	}
+	return &s.Tables[i], true
+}
+
+func (s *Schema) Has(table string) bool {
+	_, ok := s.Table(table)
+	return ok
+}
+
+// AddTable adds a table, replacing an existing one with the same name.
+func (s *Schema) AddTable(t SchemaTable) {
+	t.Name = normalizeTable(t.Name)
+	if i, ok := s.index[t.Name]; ok {
+		s.Tables[i] = t
+		return
+	}
+	s.index[t.Name] = len(s.Tables)
+	s.Tables = append(s.Tables, t)
+}
+
+// DropTable removes a table.
+func (s *Schema) DropTable(name string) {
+	i, ok := s.index[normalizeTable(name)]
+	if !ok {
+		return
+	}
+	s.Tables = append(s.Tables[:i], s.Tables[i+1:]...)
+	s.index = map[string]int{}
+	for n, t := range s.Tables {
+		s.index[t.Name] = n
+	}
+}
+
+// RenameTable renames a table, keeping its columns.
+func (s *Schema) RenameTable(from, to string) {
+	t, ok := s.Table(from)
+	if !ok {
+		return
+	}
+	renamed := *t
+	renamed.Name = to
+	s.DropTable(from)
+	s.AddTable(renamed)
+}
+
+// SetColumn adds a column to a table or replaces the column named old.
+func (t *SchemaTable) SetColumn(old string, c SchemaColumn) {
+	c.Name = normalizeTable(c.Name)
+	if existing, ok := t.Column(old); ok {
+		*existing = c
+		return
+	}
+	t.Columns = append(t.Columns, c)
+}
+
+// DropColumn removes a column from a table.
+func (t *SchemaTable) DropColumn(name string) {
+	for i := range t.Columns {
+		if strings.EqualFold(t.Columns[i].Name, normalizeTable(name)) {
+			t.Columns = append(t.Columns[:i], t.Columns[i+1:]...)
+			return
+		}
+	}
+}
+
+// Column returns a column of the table by name (case-insensitive).
+func (t *SchemaTable) Column(name string) (*SchemaColumn, bool) {
+	for i := range t.Columns {
+		if strings.EqualFold(t.Columns[i].Name, name) {
+			return &t.Columns[i], true
+		}
+	}
+	return nil, false
+}
+
+// ------------------------------------------------------------
+// SQL DUMP
+// ------------------------------------------------------------
+
+// LoadSchemaFile reads the CREATE TABLE statements of a SQL dump.
+func LoadSchemaFile(path string) (*Schema, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	schema := NewSchema(path)
+	schema.ParseSQL(string(data))
+	return schema, nil
+}
+
+var (
+	createTableRegex = regexp.MustCompile(`(?is)^create\s+(?:temporary\s+)?table\s+(?:if\s+not\s+exists\s+)?([^\s(]+)\s*(\(|like\s+([^\s;]+))`)
+	dropTableRegex   = regexp.MustCompile(`(?is)^drop\s+table\s+(?:if\s+exists\s+)?(.+)$`)
+	alterTableRegex  = regexp.MustCompile(`(?is)^alter\s+table\s+(?:if\s+exists\s+)?(\S+)\s+(.+)$`)
+	renameTableRegex = regexp.MustCompile(`(?is)^rename\s+table\s+(\S+)\s+to\s+(\S+)$`)
+)
+
+// ParseSQL applies the CREATE, ALTER, RENAME and DROP TABLE statements of
+// a SQL script, e.g. a mysqldump, to the schema.
+func (s *Schema) ParseSQL(sql string) {
+	for _, stmt := range splitSQL(sql) {
+		if m := dropTableRegex.FindStringSubmatch(stmt); m != nil {
+			for _, name := range strings.Split(m[1], ",") {
+				s.DropTable(strings.TrimSpace(name))
+			}
+			continue
+		}
+		if m := renameTableRegex.FindStringSubmatch(stmt); m != nil {
+			s.RenameTable(m[1], m[2])
+			continue
+		}
+		if m := alterTableRegex.FindStringSubmatch(stmt); m != nil {
+			s.alterTable(m[1], m[2])
+			continue
+		}
+
+		m := createTableRegex.FindStringSubmatchIndex(stmt)
+		if m == nil {
+			continue
+		}
+		name := stmt[m[2]:m[3]]
+
+		// CREATE TABLE a LIKE b
+		if m[6] >= 0 {
+			if like, ok := s.Table(stmt[m[6]:m[7]]); ok {
+				s.AddTable(SchemaTable{Name: name, Columns: append([]SchemaColumn(nil), like.Columns...)})
+			}
+			continue
+		}
+
+		open := m[4]
+		closeIdx := matchingParen(stmt, open)
+		if closeIdx < 0 {
+			continue
+		}
+		s.AddTable(SchemaTable{Name: name, Columns: parseColumnDefs(stmt[open+1 : closeIdx])})
+	}
+}
+
+// alterTable applies the column changes of an ALTER TABLE statement:
+// ADD [COLUMN], DROP [COLUMN], CHANGE, MODIFY and RENAME [TO | COLUMN].
+func (s *Schema) alterTable(name, actions string) {
+	for _, action := range splitTopLevel(actions) {
+		t, ok := s.Table(name)
+		if !ok {
+			return
+		}
+
+		fields := sqlFields(action)
+		if len(fields) < 2 {
+			continue
+		}
+		verb := strings.ToLower(fields[0])
+		rest := fields[1:]
+		if strings.EqualFold(rest[0], "column") && len(rest) > 1 {
+			rest = rest[1:]
+		}
+
+		switch verb {
+		case "add":
+			if constraintWords[strings.ToLower(rest[0])] {
+				continue
+			}
+			if cols := parseColumnDefs(strings.Join(rest, " ")); len(cols) > 0 {
+				t.SetColumn(cols[0].Name, cols[0])
+			}
+		case "drop":
+			if constraintWords[strings.ToLower(rest[0])] {
+				continue
+			}
+			t.DropColumn(rest[0])
+		case "modify":
+			if cols := parseColumnDefs(strings.Join(rest, " ")); len(cols) > 0 {
+				t.SetColumn(cols[0].Name, cols[0])
+			}
+		case "change":
+			if len(rest) > 1 {
+				if cols := parseColumnDefs(strings.Join(rest[1:], " ")); len(cols) > 0 {
+					t.SetColumn(rest[0], cols[0])
+				}
+			}
+		case "rename":
+			switch {
+			case strings.EqualFold(fields[1], "column") && len(fields) > 4:
+				if c, ok := t.Column(normalizeTable(fields[2])); ok {
+					c.Name = normalizeTable(fields[4])
+				}
+			case strings.EqualFold(rest[0], "to") || strings.EqualFold(rest[0], "as"):
+				if len(rest) > 1 {
+					s.RenameTable(name, rest[1])
+					name = rest[1]
+				}
+			default:
+				s.RenameTable(name, rest[0])
+				name = rest[0]
+			}
+		}
+	}
+}
+
+// splitSQL splits a SQL script into statements, dropping comments. Quoted
+// strings and identifiers are kept intact.
+func splitSQL(sql string) []string {
+	var stmts []string
+	var b strings.Builder
+
+	for i := 0; i < len(sql); i++ {
+		c := sql[i]
+		switch {
+		case c == '\'' || c == '"' || c == '`':
+			j := i + 1
+			for j < len(sql) && sql[j] != c {
+				if sql[j] == '\\' && c != '`' {
+					j++
+				}
+				j++
+			}
+			if j >= len(sql) {
+				j = len(sql) - 1
+			}
+			b.WriteString(sql[i : j+1])
+			i = j
+		case c == '#' || (c == '-' && strings.HasPrefix(sql[i:], "-- ")) || strings.HasPrefix(sql[i:], "--\n"):
+			for i < len(sql) && sql[i] != '\n' {
+				i++
+			}
+			b.WriteByte('\n')
+		case c == '/' && strings.HasPrefix(sql[i:], "/*"):
+			end := strings.Index(sql[i+2:], "*/")
+			if end < 0 {
+				i = len(sql)
+			} else {
+				i += end + 3
+			}
+			b.WriteByte(' ')
+		case c == ';':
+			stmts = append(stmts, strings.TrimSpace(b.String()))
+			b.Reset()
+		default:
+			b.WriteByte(c)
+		}
+	}
+	if rest := strings.TrimSpace(b.String()); rest != "" {
+		stmts = append(stmts, rest)
+	}
+
+	return stmts
+}
+
+// matchingParen returns the index of the ")" closing the "(" at open,
+// skipping quoted text, or -1.
+func matchingParen(s string, open int) int {
+	level := 0
+	for i := open; i < len(s); i++ {
+		switch c := s[i]; c {
+		case '\'', '"', '`':
+			for i++; i < len(s) && s[i] != c; i++ {
+				if s[i] == '\\' && c != '`' {
+					i++
+				}
+			}
+		case '(':
+			level++
+		case ')':
+			level--
+			if level == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// splitTopLevel splits s on commas outside parentheses and quotes.
+func splitTopLevel(s string) []string {
+	var parts []string
+	level, start := 0, 0
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; c {
+		case '\'', '"', '`':
+			for i++; i < len(s) && s[i] != c; i++ {
+				if s[i] == '\\' && c != '`' {
+					i++
+				}
+			}
+		case '(':
+			level++
+		case ')':
+			level--
+		case ',':
+			if level == 0 {
+				parts = append(parts, s[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(parts, s[start:])
+}
+
+// words that start a table constraint instead of a column definition
+var constraintWords = map[string]bool{
+	"primary": true, "key": true, "index": true, "unique": true, "constraint": true,
+	"foreign": true, "fulltext": true, "spatial": true, "check": true, "period": true,
+}
+
+// words that end the data type of a column definition
+var columnOptionWords = map[string]bool{
+	"not": true, "null": true, "default": true, "auto_increment": true, "autoincrement": true,
+	"primary": true, "unique": true, "comment": true, "references": true, "check": true,
+	"collate": true, "generated": true, "constraint": true, "on": true, "identity": true,
+}
+
+func parseColumnDefs(body string) []SchemaColumn {
+	var columns []SchemaColumn
+	var primary []string
+
+	for _, def := range splitTopLevel(body) {
+		fields := sqlFields(def)
+		if len(fields) == 0 {
+			continue
+		}
+
+		first := strings.ToLower(fields[0])
+		if constraintWords[first] {
+			// PRIMARY KEY (`id`), CONSTRAINT pk PRIMARY KEY (id)
+			lower := strings.ToLower(def)
+			if i := strings.Index(lower, "primary key"); i >= 0 {
+				if open := strings.Index(def[i:], "("); open >= 0 {
+					if closeIdx := matchingParen(def, i+open); closeIdx >= 0 {
+						for _, col := range strings.Split(def[i+open+1:closeIdx], ",") {
+							col = strings.TrimSpace(col)
+							if p := strings.IndexAny(col, " ("); p >= 0 {
+								col = col[:p]
+							}
+							primary = append(primary, normalizeTable(col))
+						}
+					}
+				}
+			}
+			continue
+		}
+
+		col := SchemaColumn{Name: normalizeTable(fields[0]), Nullable: true}
+		var typ []string
+		for i := 1; i < len(fields); i++ {
+			w := strings.ToLower(fields[i])
+			if columnOptionWords[w] || (w == "character" && i+1 < len(fields) && strings.EqualFold(fields[i+1], "set")) {
+				break
+			}
+			typ = append(typ, fields[i])
+		}
+		col.Type = strings.ToLower(strings.Join(typ, " "))
+
+		lower := strings.ToLower(" " + strings.Join(fields, " ") + " ")
+		if strings.Contains(lower, " not null ") {
+			col.Nullable = false
+		}
+		if strings.Contains(lower, " primary key ") {
+			col.PrimaryKey = true
+			col.Nullable = false
+		}
+		columns = append(columns, col)
+	}
+
+	for _, name := range primary {
+		for i := range columns {
+			if columns[i].Name == name {
+				columns[i].PrimaryKey = true
+				columns[i].Nullable = false
+			}
+		}
+	}
+
+	return columns
+}
+
+// sqlFields splits a definition into words, keeping quoted parts and
+// parenthesized type arguments (varchar(255), enum('a','b')) together.
+func sqlFields(def string) []string {
+	var fields []string
+	var b strings.Builder
+	flush := func() {
+		if b.Len() > 0 {
+			fields = append(fields, b.String())
+			b.Reset()
+		}
+	}
+
+	for i := 0; i < len(def); i++ {
+		c := def[i]
+		switch {
+		case c == '\'' || c == '"' || c == '`':
+			j := i + 1
+			for j < len(def) && def[j] != c {
+				if def[j] == '\\' && c != '`' {
+					j++
+				}
+				j++
+			}
+			if j >= len(def) {
+				j = len(def) - 1
+			}
+			b.WriteString(def[i : j+1])
+			i = j
+		case c == '(':
+			closeIdx := matchingParen(def, i)
+			if closeIdx < 0 {
+				closeIdx = len(def) - 1
+			}
+			b.WriteString(def[i : closeIdx+1])
+			i = closeIdx
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			flush()
+		default:
+			b.WriteByte(c)
+		}
+	}
+	flush()
+
+	return fields
+}
+
+// ------------------------------------------------------------
+// VALIDATION
+// ------------------------------------------------------------
+
+// SchemaCheck is the result of checking mapping rows against a schema.
+type SchemaCheck struct {
+	Group        string    // connection group the schema describes
+	Unknown      []Mapping // references to tables missing from the schema
+	Discarded    int       // unknown raw SQL references dropped as false positives
+	Unreferenced []string  // schema tables no mapping row refers to
+}
+
+// Check validates the mapping rows running on group against the schema.
+// Raw SQL references to unknown tables are almost always words picked up
+// from strings ("FROM the ...") and are dropped; unknown Query Builder
+// references are kept. Both are reported in Unknown.
+func (s *Schema) Check(mappings []Mapping, group string) ([]Mapping, SchemaCheck) {
+	check := SchemaCheck{Group: group}
+	var kept []Mapping
+	referenced := map[string]bool{}
+	seen := map[string]bool{}
+
+	for _, m := range mappings {
+		if m.Table == "" || !s.covers(m.Connection, group) {
+			kept = append(kept, m)
+			continue
+		}
+		if s.Has(m.Table) {
+			referenced[normalizeTable(m.Table)] = true
+			kept = append(kept, m)
+			continue
+		}
+
+		key := m.ModelFile + "|" + m.Model + "|" + m.Table + "|" + m.Operation + "|" + m.Source
+		if !seen[key] {
+			seen[key] = true
+			check.Unknown = append(check.Unknown, m)
+			if m.Source == SourceRawSQL {
+				check.Discarded++
+			}
+		}
+		if m.Source == SourceRawSQL {
+			continue
+		}
+		kept = append(kept, m)
+	}
+
+	for _, t := range s.Tables {
+		if !referenced[t.Name] {
+			check.Unreferenced = append(check.Unreferenced, t.Name)
+		}
+	}
+	sort.Strings(check.Unreferenced)
+
+	return kept, check
+}
+
+// FilterLineage drops the lineage rows with raw SQL references to tables
+// missing from the schema, like Check does for mapping rows.
+func (s *Schema) FilterLineage(rows []LineageRow, group string) []LineageRow {
+	var kept []LineageRow
+	for _, r := range rows {
+		if r.Table != "" && r.Source == SourceRawSQL && s.covers(r.Connection, group) && !s.Has(r.Table) {
+			continue
+		}
+		kept = append(kept, r)
+	}
+	return kept
+}
+
+// FilterColumns drops the raw SQL column references to tables missing
+// from the schema.
+func (s *Schema) FilterColumns(columns []ColumnMapping, group string) []ColumnMapping {
+	var kept []ColumnMapping
+	for _, c := range columns {
+		if c.Source == SourceRawSQL && s.covers(c.Connection, group) && !s.Has(c.Table) {
+			continue
+		}
+		kept = append(kept, c)
+	}
+	return kept
+}
+
+func (s *Schema) covers(connection, group string) bool {
+	return group == "" || connection == "" || connection == group
+}
+
+// CheckColumns returns the column references on group to tables of the
+// schema that lack the column.
+func (s *Schema) CheckColumns(columns []ColumnMapping, group string) []ColumnMapping {
+	var unknown []ColumnMapping
+	seen := map[string]bool{}
+	for _, c := range columns {
+		if !s.covers(c.Connection, group) {
+			continue
+		}
+		t, ok := s.Table(c.Table)
+		if !ok {
+			continue
+		}
+		if _, ok := t.Column(c.Column); ok {
+			continue
+		}
+		key := c.ModelFile + "|" + c.Model + "|" + c.ModelMethod + "|" + c.Table + "|" + c.Column
+		if !seen[key] {
+			seen[key] = true
+			unknown = append(unknown, c)
+		}
+	}
+	return unknown
+}
@@ -0,0 +1,129 @@
+package analyzer
+
+import "database/sql"
+
+// saveSchema stores the tables and columns of a schema under a report.
+func saveSchema(db *sql.DB, reportID int64, schema *Schema) error {
+	return execOps(db, schemaOps(reportID, schema))
+}
+
+// execOps runs the statements of saveSchema and saveSchemaCheck, outside
+// of a report writer.
+func execOps(db *sql.DB, ops []writeOp) error {
+	for _, op := range ops {
+		if _, err := db.Exec(op.query, op.args...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func schemaOps(reportID int64, schema *Schema) []writeOp {
+	var ops []writeOp
+	for _, t := range schema.Tables {
+		ops = append(ops, writeOp{`
+		INSERT INTO schema_tables (report_id, table_name, source)
+		VALUES (?, ?, ?)`, []any{
+			reportID,
+			t.Name,
+			schema.Source,
+		}})
+
+		for _, c := range t.Columns {
+			ops = append(ops, writeOp{`
+			INSERT INTO schema_columns
+			(report_id, table_name, column_name, data_type, nullable, primary_key)
+			VALUES (?, ?, ?, ?, ?, ?)`, []any{
+				reportID,
+				t.Name,
+				c.Name,
+				c.Type,
+				c.Nullable,
+				c.PrimaryKey,
+			}})
+		}
+	}
+	return ops
+}
+
+// saveSchemaCheck stores the unknown table references of a check and marks
+// which schema tables are referenced by code.
+func saveSchemaCheck(db *sql.DB, reportID int64, check SchemaCheck) error {
+	return execOps(db, schemaCheckOps(reportID, check))
+}
+
+func schemaCheckOps(reportID int64, check SchemaCheck) []writeOp {
+	var ops []writeOp
+	for _, m := range check.Unknown {
+		ops = append(ops, writeOp{`
+		INSERT INTO unknown_table_refs
+		(report_id, module, controller_file, model, model_method, model_file, table_name, operation, source, connection, discarded)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`, []any{
+			reportID,
+			m.Module,
+			m.ControllerFile,
+			m.Model,
+			m.ModelMethod,
+			m.ModelFile,
+			m.Table,
+			m.Operation,
+			m.Source,
+			m.Connection,
+			m.Source == SourceRawSQL,
+		}})
+	}
+
+	ops = append(ops, writeOp{`UPDATE schema_tables SET referenced = 1 WHERE report_id = ?`, []any{reportID}})
+	for _, table := range check.Unreferenced {
+		ops = append(ops, writeOp{
+			`UPDATE schema_tables SET referenced = 0 WHERE report_id = ? AND table_name = ?`,
+			[]any{reportID, table},
+		})
+	}
+	return ops
+}
+
+// loadReportSchema reads back the schema stored under a report. It returns
+// nil if the report has none.
+func loadReportSchema(db *sql.DB, reportID int64) (*Schema, error) {
+	rows, err := db.Query(`
+	SELECT t.table_name, t.source, c.column_name, c.data_type, c.nullable, c.primary_key
+	FROM schema_tables t
+	LEFT JOIN schema_columns c ON c.report_id = t.report_id AND c.table_name = t.table_name
+	WHERE t.report_id = ?
+	ORDER BY t.id, c.id`,
+		reportID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var schema *Schema
+	for rows.Next() {
+		var table, source string
+		var column, dataType sql.NullString
+		var nullable, primaryKey sql.NullBool
+		if err := rows.Scan(&table, &source, &column, &dataType, &nullable, &primaryKey); err != nil {
+			return nil, err
+		}
+		if schema == nil {
+			schema = NewSchema(source)
+		}
+		t, ok := schema.Table(table)
+		if !ok {
+			schema.AddTable(SchemaTable{Name: table})
+			t, _ = schema.Table(table)
+		}
+		if column.Valid {
+			t.Columns = append(t.Columns, SchemaColumn{
+				Name:       column.String,
+				Type:       dataType.String,
+				Nullable:   nullable.Bool,
+				PrimaryKey: primaryKey.Bool,
+			})
+		}
+	}
+
+	return schema, rows.Err()
+}
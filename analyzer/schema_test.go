@@ -0,0 +1,82 @@
+package analyzer
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// schemaColumns describes the tables of a schema as
+// "table: column type [pk] [null], ...".
+func schemaColumns(s *Schema) []string {
+	var tables []string
+	for _, t := range s.Tables {
+		var cols []string
+		for _, c := range t.Columns {
+			col := c.Name + " " + c.Type
+			if c.PrimaryKey {
+				col += " pk"
+			}
+			if c.Nullable {
+				col += " null"
+			}
+			cols = append(cols, col)
+		}
+		tables = append(tables, t.Name+": "+strings.Join(cols, ", "))
+	}
+	return tables
+}
+
+func TestSchemaParseSQL(t *testing.T) {
+	s := NewSchema("dump.sql")
+	s.ParseSQL("-- MySQL dump\n" + `
+/*!40101 SET NAMES utf8 */;
+CREATE TABLE ` + "`ci_users`" + ` (
+  ` + "`id`" + ` int(11) unsigned NOT NULL AUTO_INCREMENT,
+  ` + "`email`" + ` varchar(255) NOT NULL,
+  ` + "`name`" + ` varchar(100) DEFAULT NULL,
+  ` + "`status`" + ` enum('a','b') NOT NULL DEFAULT 'a',
+  PRIMARY KEY (` + "`id`" + `),
+  KEY ` + "`email`" + ` (` + "`email`" + `)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8;
+CREATE TABLE ci_posts (id INT PRIMARY KEY, title TEXT, body TEXT COMMENT 'a; b');
+CREATE TABLE ci_tmp LIKE ci_posts;
+CREATE TABLE ci_old (id INT);
+
+ALTER TABLE ci_users ADD COLUMN phone varchar(20), ADD INDEX idx_phone (phone),
+  CHANGE name full_name varchar(150) NOT NULL, MODIFY status varchar(10);
+ALTER TABLE ci_users RENAME COLUMN email TO mail;
+ALTER TABLE ci_posts DROP COLUMN body, RENAME TO ci_articles;
+RENAME TABLE ci_tmp TO ci_drafts;
+DROP TABLE IF EXISTS ci_drafts, ci_missing;
+DROP TABLE ci_old;
+`)
+
+	want := []string{
+		"ci_users: id int(11) unsigned pk, mail varchar(255), full_name varchar(150), status varchar(10) null, phone varchar(20) null",
+		"ci_articles: id int pk, title text null",
+	}
+	if got := schemaColumns(s); !reflect.DeepEqual(got, want) {
+		t.Errorf("schema =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+	if !s.Has("`CI_Articles`") || s.Has("ci_posts") {
+		t.Error("Has does not follow the renamed table")
+	}
+}
+
+func TestSchemaParseSQLSwapPrefix(t *testing.T) {
+	ctx := TableContext{
+		DB: &DatabaseConfig{ActiveGroup: "default", Groups: []DBGroup{
+			{Name: "default", Prefix: "ci_", SwapPre: "{PRE}"},
+		}},
+		Group: "default",
+	}
+
+	s := NewSchema("")
+	s.ParseSQL(ctx.swapPrefix("CREATE TABLE {PRE}logs (id INT NOT NULL, msg TEXT); ALTER TABLE {PRE}logs ADD level INT", ctx.Group))
+
+	want := []string{"ci_logs: id int, msg text null, level int null"}
+	if got := schemaColumns(s); !reflect.DeepEqual(got, want) {
+		t.Errorf("schema = %v, want %v", got, want)
+	}
+}
@@ -187,8 +187,17 @@ func detectMissingValidation(code, filePath string) []SecurityWarning {
 // AnalyzeSecurity runs all security checks and returns
 // a consolidated list of warnings.
 func AnalyzeSecurity(code, filePath string) []SecurityWarning {
+	return AnalyzeSecurityTokens(Tokenize(code), filePath)
+}
+
+// AnalyzeSecurityTokens runs all security checks on an already tokenized
+// file. Comments are blanked out first so commented-out code is not
+// reported; line numbers are preserved.
+func AnalyzeSecurityTokens(tokens []Token, filePath string) []SecurityWarning {
 	var warnings []SecurityWarning
 
+	code := StripComments(tokens)
+
 	warnings = append(warnings, detectRawSQL(code, filePath)...)
 	warnings = append(warnings, detectXSS(code, filePath)...)
 	warnings = append(warnings, detectFileUploadIssues(code, filePath)...)
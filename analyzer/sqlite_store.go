@@ -0,0 +1,119 @@
+/*
+Copyright © 2025 Vicky Chhetri <vickychhetri4@gmail.com>
+
+SQLiteStore: the Store of ci3-analyzer.db, on top of the *_store.go
+functions.
+*/
+
+package analyzer
+
+import "database/sql"
+
+// SQLiteStore is the Store of ci3-analyzer.db.
+type SQLiteStore struct {
+	db *sql.DB
+}
+
+// NewSQLiteStore wraps a database that is already at the latest schema
+// version.
+func NewSQLiteStore(db *sql.DB) *SQLiteStore {
+	return &SQLiteStore{db: db}
+}
+
+// OpenSQLiteStore opens (and upgrades) the database at path.
+func OpenSQLiteStore(path string) (*SQLiteStore, error) {
+	db, err := OpenDB(path)
+	if err != nil {
+		return nil, err
+	}
+	return NewSQLiteStore(db), nil
+}
+
+// DB returns the underlying database.
+func (s *SQLiteStore) DB() *sql.DB {
+	return s.db
+}
+
+func (s *SQLiteStore) CreateReport(reportType, projectPath string) (int64, error) {
+	return createReport(s.db, reportType, projectPath)
+}
+
+func (s *SQLiteStore) CompleteReport(reportID int64) error {
+	return completeReport(s.db, reportID)
+}
+
+func (s *SQLiteStore) FailReport(reportID int64, cause error) error {
+	return failReport(s.db, reportID, cause)
+}
+
+func (s *SQLiteStore) LatestReportID(reportType string) (int64, error) {
+	return latestReportID(s.db, reportType)
+}
+
+func (s *SQLiteStore) LatestProjectReportID(reportType, projectPath string) (int64, error) {
+	return latestProjectReportID(s.db, reportType, projectPath)
+}
+
+func (s *SQLiteStore) ReportProjectPath(reportID int64) (string, error) {
+	return reportProjectPath(s.db, reportID)
+}
+
+func (s *SQLiteStore) ListProjects() ([]Project, error) {
+	return listProjects(s.db)
+}
+
+func (s *SQLiteStore) NewWriter(reportID int64) ReportWriter {
+	return newReportWriter(s.db, reportID)
+}
+
+func (s *SQLiteStore) SaveMapping(reportID int64, m Mapping) error {
+	return saveMapping(s.db, reportID, m)
+}
+
+func (s *SQLiteStore) SaveUnresolvedLoad(reportID int64, u UnresolvedLoad) error {
+	return saveUnresolvedLoad(s.db, reportID, u)
+}
+
+func (s *SQLiteStore) SaveColumnMapping(reportID int64, c ColumnMapping) error {
+	return saveColumnMapping(s.db, reportID, c)
+}
+
+func (s *SQLiteStore) SaveRoute(reportID int64, route Route) error {
+	return saveRoute(s.db, reportID, route)
+}
+
+func (s *SQLiteStore) SaveLineage(reportID int64, row LineageRow) error {
+	return saveLineage(s.db, reportID, row)
+}
+
+func (s *SQLiteStore) SaveSchema(reportID int64, schema *Schema) error {
+	return saveSchema(s.db, reportID, schema)
+}
+
+func (s *SQLiteStore) SaveSchemaCheck(reportID int64, check SchemaCheck) error {
+	return saveSchemaCheck(s.db, reportID, check)
+}
+
+func (s *SQLiteStore) SaveScan(reportID int64, reports []ModuleReport) error {
+	return saveScan(s.db, reportID, reports)
+}
+
+func (s *SQLiteStore) LoadMappings(reportID int64) ([]Mapping, error) {
+	return loadMappings(s.db, reportID)
+}
+
+func (s *SQLiteStore) LoadLineage(reportID int64) ([]LineageRow, error) {
+	return loadLineage(s.db, reportID)
+}
+
+func (s *SQLiteStore) LoadColumnMappings(reportID int64) ([]ColumnMapping, error) {
+	return loadColumnMappings(s.db, reportID)
+}
+
+func (s *SQLiteStore) LoadReportSchema(reportID int64) (*Schema, error) {
+	return loadReportSchema(s.db, reportID)
+}
+
+func (s *SQLiteStore) Close() error {
+	return s.db.Close()
+}
@@ -0,0 +1,67 @@
+/*
+Copyright © 2025 Vicky Chhetri <vickychhetri4@gmail.com>
+
+Store: where the analyzer keeps its reports. SQLiteStore is the one the
+commands use; MemoryStore keeps everything in memory, for tools that embed
+the analyzer and for tests.
+*/
+
+package analyzer
+
+import "fmt"
+
+// Store keeps the reports of the scan and map commands and the rows they
+// produce.
+type Store interface {
+	// CreateReport starts a running report of the given type for a project.
+	CreateReport(reportType, projectPath string) (int64, error)
+	CompleteReport(reportID int64) error
+	FailReport(reportID int64, cause error) error
+
+	// LatestReportID and LatestProjectReportID only return complete
+	// reports.
+	LatestReportID(reportType string) (int64, error)
+	LatestProjectReportID(reportType, projectPath string) (int64, error)
+	ReportProjectPath(reportID int64) (string, error)
+	ListProjects() ([]Project, error)
+
+	// NewWriter writes the rows of a report as one unit: they are stored
+	// when Close returns nil and not at all otherwise.
+	NewWriter(reportID int64) ReportWriter
+
+	SaveMapping(reportID int64, m Mapping) error
+	SaveUnresolvedLoad(reportID int64, u UnresolvedLoad) error
+	SaveColumnMapping(reportID int64, c ColumnMapping) error
+	SaveRoute(reportID int64, route Route) error
+	SaveLineage(reportID int64, row LineageRow) error
+	SaveSchema(reportID int64, schema *Schema) error
+	SaveSchemaCheck(reportID int64, check SchemaCheck) error
+	SaveScan(reportID int64, reports []ModuleReport) error
+
+	LoadMappings(reportID int64) ([]Mapping, error)
+	LoadLineage(reportID int64) ([]LineageRow, error)
+	LoadColumnMappings(reportID int64) ([]ColumnMapping, error)
+	LoadReportSchema(reportID int64) (*Schema, error)
+
+	Close() error
+}
+
+// ReportWriter queues the rows of one report. Nothing may be written after
+// Close, which returns the first error.
+type ReportWriter interface {
+	Mapping(m Mapping)
+	UnresolvedLoad(u UnresolvedLoad)
+	ColumnMapping(c ColumnMapping)
+	Route(route Route)
+	Lineage(row LineageRow)
+	Schema(schema *Schema)
+	SchemaCheck(check SchemaCheck)
+	Close() error
+}
+
+func noReportError(reportType, projectPath string) error {
+	if projectPath != "" {
+		return fmt.Errorf("no %s report found for %s, run the %s command first", reportType, projectPath, reportType)
+	}
+	return fmt.Errorf("no %s report found, run the %s command first", reportType, reportType)
+}
@@ -0,0 +1,653 @@
+/*
+Copyright © 2025 Vicky Chhetri <vickychhetri4@gmail.com>
+
+Table extraction for CI3 models: Query Builder chains and raw SQL, with
+the kind of access (SELECT, INSERT, UPDATE, DELETE, ...) for each table.
+Table names are resolved to the physical tables: dbprefix, swap_pre,
+$this->db->dbprefix('x') and class properties like $this->table.
+*/
+
+package analyzer
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Table access operations
+const (
+	OpSelect   = "SELECT"
+	OpInsert   = "INSERT"
+	OpUpdate   = "UPDATE"
+	OpDelete   = "DELETE"
+	OpReplace  = "REPLACE"
+	OpTruncate = "TRUNCATE"
+	OpCreate   = "CREATE"
+	OpAlter    = "ALTER"
+	OpDrop     = "DROP"
+)
+
+// Where a table reference was found
+const (
+	SourceQueryBuilder = "query_builder"
+	SourceRawSQL       = "raw_sql"
+)
+
+type TableRef struct {
+	Table      string
+	Operation  string
+	Source     string
+	Connection string // database.php group the query runs on
+	Line       int
+}
+
+// IsWrite reports whether the operation modifies data or structure.
+func (r TableRef) IsWrite() bool {
+	return r.Operation != OpSelect
+}
+
+// ------------------------------------------------------------
+// PATTERN DEFINITIONS (CI3 REAL-WORLD SUPPORT)
+// ------------------------------------------------------------
+
+// Query Builder methods whose first argument is a table, with the
+// operation they perform. "" means the operation depends on the rest of
+// the chain: from() and join() feed whatever get()/update()/delete() ends
+// the statement and default to SELECT.
+var qbTableMethods = map[string]string{
+	"from":              "",
+	"join":              "",
+	"get":               OpSelect,
+	"get_where":         OpSelect,
+	"count_all":         OpSelect,
+	"count_all_results": OpSelect,
+	"insert":            OpInsert,
+	"insert_batch":      OpInsert,
+	"replace":           OpReplace,
+	"update":            OpUpdate,
+	"update_batch":      OpUpdate,
+	"delete":            OpDelete,
+	"empty_table":       OpDelete,
+	"truncate":          OpTruncate,
+}
+
+// SQL table extraction
+// FROM table | JOIN table | INSERT INTO table | UPDATE table | DELETE FROM table
+// REPLACE INTO table | TRUNCATE table | CREATE/ALTER/DROP TABLE table
+var sqlTableRegex = regexp.MustCompile(
+	"(?i)\\b(insert\\s+(?:ignore\\s+)?into|replace\\s+into|delete\\s+from|update|" +
+		"truncate(?:\\s+table)?|create\\s+(?:temporary\\s+)?table(?:\\s+if\\s+not\\s+exists)?|" +
+		"alter\\s+table|drop\\s+table(?:\\s+if\\s+exists)?|from|join)\\s+`?([a-zA-Z0-9_]+(?:`?\\.`?[a-zA-Z0-9_]+)?)`?",
+)
+
+// strings that start like a SQL statement
+var sqlStatementRegex = regexp.MustCompile(`(?i)^\s*\(?\s*(select|insert|replace|update|delete|truncate|create|alter|drop)\s`)
+
+// "ON DUPLICATE KEY UPDATE col = ..." is not a table reference
+var sqlDuplicateKeyRegex = regexp.MustCompile(`(?i)\bkey\s*$`)
+
+func sqlOperation(keyword string) string {
+	keyword = strings.ToLower(keyword)
+	switch {
+	case strings.HasPrefix(keyword, "insert"):
+		return OpInsert
+	case strings.HasPrefix(keyword, "replace"):
+		return OpReplace
+	case strings.HasPrefix(keyword, "delete"):
+		return OpDelete
+	case keyword == "update":
+		return OpUpdate
+	case strings.HasPrefix(keyword, "truncate"):
+		return OpTruncate
+	case strings.HasPrefix(keyword, "create"):
+		return OpCreate
+	case strings.HasPrefix(keyword, "alter"):
+		return OpAlter
+	case strings.HasPrefix(keyword, "drop"):
+		return OpDrop
+	}
+	return OpSelect
+}
+
+// ------------------------------------------------------------
+// TABLE NAMES (dbprefix, table properties)
+// ------------------------------------------------------------
+
+// TableContext turns the table arguments found in code into physical
+// table names and tells which connection group each query runs on. The
+// zero value keeps names as written (normalized).
+type TableContext struct {
+	DB          *DatabaseConfig
+	Group       string            // group $this->db points to
+	Connections map[string]string // other handles ("reporting" for $this->reporting, "$rdb") → group
+	Properties  map[string]string // string properties of the class, e.g. table → users
+}
+
+// DBTableContext returns the context of a project: the groups of
+// application/config/database.php, with $this->db on the active group.
+func DBTableContext(projectPath string) TableContext {
+	dbc, err := ParseDatabaseConfig(projectPath)
+	if err != nil {
+		return TableContext{}
+	}
+	return TableContext{DB: dbc, Group: dbc.ActiveGroup}
+}
+
+// ForClass returns the context extended with the string properties of a
+// class: declared defaults (inherited ones first, so a subclass overrides
+// its MY_Model base) and $this->x = '...' assignments in the constructor.
+func (ctx TableContext) ForClass(c *PHPClass) TableContext {
+	props := map[string]string{}
+	for k, v := range ctx.Properties {
+		props[k] = v
+	}
+
+	for i := len(c.InheritedProperties) - 1; i >= 0; i-- {
+		if v, ok := c.InheritedProperties[i].StringDefault(); ok {
+			props[c.InheritedProperties[i].Name] = v
+		}
+	}
+	for _, p := range c.Properties {
+		if v, ok := p.StringDefault(); ok {
+			props[p.Name] = v
+		}
+	}
+
+	var constructors []PHPMethod
+	for _, m := range c.InheritedMethods {
+		if strings.EqualFold(m.Name, "__construct") {
+			constructors = append(constructors, m.PHPMethod)
+		}
+	}
+	for _, m := range c.Methods {
+		if strings.EqualFold(m.Name, "__construct") {
+			constructors = append(constructors, m)
+		}
+	}
+	for _, m := range constructors {
+		body := m.body
+		for i := 0; i+4 < len(body); i++ {
+			if body[i].Is(TokenVariable, "$this") && body[i+1].IsOp("->") && body[i+2].Kind == TokenIdent &&
+				body[i+3].IsOp("=") && body[i+4].IsStringLiteral() &&
+				(i+5 == len(body) || body[i+5].IsOp(";")) {
+				props[body[i+2].Text] = body[i+4].StringValue()
+			}
+		}
+	}
+
+	ctx.Properties = props
+
+	// $this->reporting = $this->load->database('reporting', TRUE) and
+	// $this->load->database('reporting') anywhere in the class
+	conns := map[string]string{}
+	for k, v := range ctx.Connections {
+		conns[k] = v
+	}
+	active := ""
+	if ctx.DB != nil {
+		active = ctx.DB.ActiveGroup
+	}
+	var methods []PHPMethod
+	for _, m := range c.InheritedMethods {
+		methods = append(methods, m.PHPMethod)
+	}
+	methods = append(methods, c.Methods...)
+	for _, m := range methods {
+		targets, group := databaseLoads(m.body, active)
+		for target, g := range targets {
+			if !strings.HasPrefix(target, "$") {
+				conns[target] = g
+			}
+		}
+		if group != "" {
+			ctx.Group = group
+		}
+	}
+	if g, ok := conns["db"]; ok {
+		ctx.Group = g
+	}
+	ctx.Connections = conns
+
+	return ctx
+}
+
+// forBody returns the context extended with the local handles of a body,
+// e.g. $rdb = $this->load->database('reporting', TRUE).
+func (ctx TableContext) forBody(sig []Token) TableContext {
+	active := ""
+	if ctx.DB != nil {
+		active = ctx.DB.ActiveGroup
+	}
+	targets, _ := databaseLoads(sig, active)
+	if len(targets) == 0 {
+		return ctx
+	}
+
+	conns := map[string]string{}
+	for k, v := range ctx.Connections {
+		conns[k] = v
+	}
+	for target, g := range targets {
+		if strings.HasPrefix(target, "$") {
+			conns[target] = g
+		}
+	}
+	ctx.Connections = conns
+	return ctx
+}
+
+// group returns the settings of a connection group.
+func (ctx TableContext) group(name string) DBGroup {
+	g, _ := ctx.DB.Group(name)
+	return g
+}
+
+// receiverAt recognizes a database handle starting at sig[i] and returns
+// its group and length: $this->db, $this->CI->db, $CI->db,
+// get_instance()->db, $this->reporting and $rdb (the last two when loaded
+// with $this->load->database()).
+func (ctx TableContext) receiverAt(sig []Token, i int) (group string, n int, ok bool) {
+	at := func(j int, op string) bool { return j < len(sig) && sig[j].IsOp(op) }
+	ident := func(j int, name string) bool {
+		return j < len(sig) && sig[j].Kind == TokenIdent && (name == "" || sig[j].Text == name)
+	}
+
+	t := sig[i]
+	switch {
+	case t.IsKeyword("get_instance") && at(i+1, "(") && at(i+2, ")") && at(i+3, "->") && ident(i+4, "db") && at(i+5, "->"):
+		return ctx.Group, 5, true
+	case t.Is(TokenVariable, "$this") && at(i+1, "->") && ident(i+2, "") && at(i+3, "->") && ident(i+4, "db") && at(i+5, "->"):
+		return ctx.Group, 5, true
+	case t.Is(TokenVariable, "$this") && at(i+1, "->") && ident(i+2, "db") && at(i+3, "->"):
+		return ctx.Group, 3, true
+	case t.Is(TokenVariable, "$this") && at(i+1, "->") && ident(i+2, "") && at(i+3, "->"):
+		if g, ok := ctx.Connections[sig[i+2].Text]; ok {
+			return g, 3, true
+		}
+	case t.Kind == TokenVariable && at(i+1, "->") && ident(i+2, "db") && at(i+3, "->"):
+		return ctx.Group, 3, true
+	case t.Kind == TokenVariable && at(i+1, "->"):
+		if g, ok := ctx.Connections[t.Text]; ok {
+			return g, 1, true
+		}
+	}
+	return "", 0, false
+}
+
+// normalizeTable strips quoting and a database qualifier and lowercases
+// the name: "`Shop`.`Users`" becomes "users".
+func normalizeTable(name string) string {
+	name = strings.NewReplacer("`", "", `"`, "", "[", "", "]", "").Replace(name)
+	if i := strings.LastIndex(name, "."); i >= 0 {
+		name = name[i+1:]
+	}
+	return strings.ToLower(name)
+}
+
+// qbTable returns the physical name of a Query Builder table argument.
+// Like CI's protect_identifiers(), the prefix of the group is only added
+// to names that do not carry it already (e.g. the result of
+// $this->db->dbprefix()).
+func (ctx TableContext) qbTable(name, group string) string {
+	name = normalizeTable(name)
+	prefix := strings.ToLower(ctx.group(group).Prefix)
+	if name == "" || prefix == "" || strings.HasPrefix(name, prefix) {
+		return name
+	}
+	return prefix + name
+}
+
+// swapPrefix replaces swap_pre with dbprefix in raw SQL, as CI's query()
+// does before running it.
+func (ctx TableContext) swapPrefix(sql, group string) string {
+	g := ctx.group(group)
+	if g.SwapPre == "" || g.Prefix == "" || g.SwapPre == g.Prefix {
+		return sql
+	}
+	return strings.ReplaceAll(sql, g.SwapPre, g.Prefix)
+}
+
+var interpolatedProperty = regexp.MustCompile(`\{?\$this->([A-Za-z_][A-Za-z0-9_]*)\}?`)
+
+// concat evaluates the string concatenation starting at sig[i]: string
+// literals, $this->db->dbprefix('x') calls and table properties. Parts that
+// cannot be resolved become " ? ". It returns the text, the index after the
+// expression and whether every part was resolved.
+func (ctx TableContext) concat(sig []Token, i int) (string, int, bool) {
+	var b strings.Builder
+	resolved := true
+
+	for i < len(sig) {
+		v, next, ok := ctx.term(sig, i)
+		if next == i {
+			break
+		}
+		if ok {
+			b.WriteString(v)
+		} else {
+			b.WriteString(" ? ")
+			resolved = false
+		}
+		i = next
+		if i >= len(sig) || !sig[i].IsOp(".") {
+			break
+		}
+		i++
+	}
+
+	return b.String(), i, resolved
+}
+
+// term evaluates one operand of a concatenation. next == i when sig[i]
+// does not start an operand.
+func (ctx TableContext) term(sig []Token, i int) (value string, next int, ok bool) {
+	t := sig[i]
+	if t.IsStringLiteral() {
+		v := t.StringValue()
+		if t.Kind == TokenString && strings.HasPrefix(t.Text, `"`) {
+			v = interpolatedProperty.ReplaceAllStringFunc(v, func(m string) string {
+				name := interpolatedProperty.FindStringSubmatch(m)[1]
+				if p, ok := ctx.Properties[name]; ok {
+					return p
+				}
+				return " ? "
+			})
+		}
+		return v, i + 1, true
+	}
+
+	start := i
+	// (int) $id
+	if t.IsOp("(") && i+3 < len(sig) && sig[i+1].Kind == TokenIdent && sig[i+2].IsOp(")") && sig[i+3].Kind == TokenVariable {
+		i += 3
+	}
+	switch {
+	case sig[i].IsOp("("):
+		i = matchingClose(sig, i) + 1
+	case sig[i].Kind == TokenVariable, sig[i].Kind == TokenIdent, sig[i].Kind == TokenNumber:
+		i++
+	default:
+		return "", start, false
+	}
+
+postfix:
+	for i < len(sig) {
+		switch {
+		case (sig[i].IsOp("->") || sig[i].IsOp("::") || sig[i].IsOp("?->")) && i+1 < len(sig):
+			i += 2
+		case sig[i].IsOp("(") || sig[i].IsOp("["):
+			i = matchingClose(sig, i) + 1
+		default:
+			break postfix
+		}
+	}
+
+	expr := sig[start:i]
+	n := len(expr)
+	switch {
+	// $this->table
+	case n == 3 && expr[0].Is(TokenVariable, "$this") && expr[1].IsOp("->") && expr[2].Kind == TokenIdent:
+		v, ok := ctx.Properties[expr[2].Text]
+		return v, i, ok
+
+	// self::$table, static::$table
+	case n == 3 && (expr[0].IsKeyword("self") || expr[0].IsKeyword("static")) && expr[1].IsOp("::") && expr[2].Kind == TokenVariable:
+		v, ok := ctx.Properties[strings.TrimPrefix(expr[2].Text, "$")]
+		return v, i, ok
+
+	// ...->dbprefix('users')
+	case n >= 5 && expr[n-5].IsOp("->") && expr[n-4].IsKeyword("dbprefix") &&
+		expr[n-3].IsOp("(") && expr[n-2].IsStringLiteral() && expr[n-1].IsOp(")"):
+		group := ctx.Group
+		if g, _, ok := ctx.receiverAt(expr, 0); ok {
+			group = g
+		}
+		return ctx.group(group).Prefix + expr[n-2].StringValue(), i, true
+	}
+
+	return "", i, false
+}
+
+// ------------------------------------------------------------
+// MODEL → TABLES (MAIN LOGIC)
+// ------------------------------------------------------------
+
+// ExtractTables finds all tables used in a model file
+func ExtractTables(code string) []string {
+	return ExtractTablesFromTokens(Tokenize(code))
+}
+
+// ExtractTablesFromTokens finds all tables used in an already tokenized
+// model file (or any slice of it, e.g. a single method body).
+func ExtractTablesFromTokens(tokens []Token) []string {
+	var tables []string
+	for _, ref := range ExtractTableRefs(tokens) {
+		tables = append(tables, ref.Table)
+	}
+	return unique(tables)
+}
+
+// ExtractTableRefs finds every table reference with its operation and
+// source. Duplicate (table, operation, source) references are dropped.
+func ExtractTableRefs(tokens []Token) []TableRef {
+	return TableContext{}.ExtractTableRefs(tokens)
+}
+
+// ExtractTableRefs is like the package level ExtractTableRefs, resolving
+// table names and connection groups through the context.
+func (ctx TableContext) ExtractTableRefs(tokens []Token) []TableRef {
+	var refs []TableRef
+
+	sig := SignificantTokens(tokens)
+	ctx = ctx.forBody(sig)
+	used := map[string]bool{}
+
+	start := 0
+	for i := 0; i <= len(sig); i++ {
+		if i < len(sig) && !sig[i].IsOp(";") && !sig[i].IsOp("{") && !sig[i].IsOp("}") {
+			continue
+		}
+		stmtRefs, groups := ctx.statementTableRefs(sig[start:i])
+		refs = append(refs, stmtRefs...)
+		for _, g := range groups {
+			used[g] = true
+		}
+		start = i + 1
+	}
+
+	// SQL built in a separate statement ($sql = "SELECT ...") runs on the
+	// only connection the code uses, or on $this->db
+	fallback := ctx.Group
+	if len(used) == 1 {
+		for g := range used {
+			fallback = g
+		}
+	}
+	for i := range refs {
+		if refs[i].Connection == "" {
+			refs[i].Connection = fallback
+		}
+	}
+
+	return uniqueTableRefs(refs)
+}
+
+// statementTableRefs extracts the table references of a single statement
+// and returns the connection groups of the database handles it uses. Raw
+// SQL in a statement without a handle gets no connection.
+func (ctx TableContext) statementTableRefs(stmt []Token) ([]TableRef, []string) {
+	var refs []TableRef
+	var groups []string
+
+	// connection of the handle each token is chained on, and the "->" of
+	// the calls chained directly on a handle ($this->db->where()->get())
+	conn := make([]string, len(stmt))
+	chained := map[int]string{}
+	current := ""
+	for i := 0; i < len(stmt); i++ {
+		if g, n, ok := ctx.receiverAt(stmt, i); ok {
+			current = g
+			groups = append(groups, g)
+			for k := i; k < i+n; k++ {
+				conn[k] = current
+			}
+			for j := i + n; j+2 < len(stmt) && stmt[j].IsOp("->") && stmt[j+1].Kind == TokenIdent && stmt[j+2].IsOp("("); {
+				chained[j] = g
+				j = matchingClose(stmt, j+2) + 1
+			}
+			i += n - 1
+			continue
+		}
+		conn[i] = current
+	}
+
+	// --------------------------------------------
+	// 1. Query Builder (CI3 style)
+	// --------------------------------------------
+	type qbCall struct {
+		method string
+		table  string
+		group  string
+		line   int
+	}
+	var calls []qbCall
+	terminal := ""
+
+	for i := 0; i+2 < len(stmt); i++ {
+		// get(), insert()... on anything but a database handle
+		// ($this->input->get('page'), $this->cache->get('key')) are not
+		// table accesses
+		group, ok := chained[i]
+		if !ok {
+			continue
+		}
+		method := strings.ToLower(stmt[i+1].Text)
+		op, ok := qbTableMethods[method]
+		if !ok {
+			continue
+		}
+		if op != "" {
+			terminal = op
+		}
+
+		table := ""
+		if i+3 < len(stmt) {
+			if v, end, ok := ctx.concat(stmt, i+3); ok && end < len(stmt) && (stmt[end].IsOp(",") || stmt[end].IsOp(")")) {
+				table = ctx.qbTable(qbTableName(v), group)
+			}
+		}
+		calls = append(calls, qbCall{method: method, table: table, group: group, line: stmt[i+1].Line})
+	}
+
+	for _, c := range calls {
+		if c.table == "" {
+			continue
+		}
+		op := qbTableMethods[c.method]
+		if op == "" {
+			op = terminal
+		}
+		if op == "" {
+			op = OpSelect
+		}
+		refs = append(refs, TableRef{Table: c.table, Operation: op, Source: SourceQueryBuilder, Connection: c.group, Line: c.line})
+	}
+
+	// --------------------------------------------
+	// 2. Raw SQL ($this->db->query and SQL built in strings,
+	//    including "... FROM " . $this->table concatenations).
+	//    Other strings ("Please update your profile") are not SQL.
+	// --------------------------------------------
+	for i := 0; i < len(stmt); i++ {
+		if !stmt[i].IsStringLiteral() || (i > 0 && stmt[i-1].IsOp(".")) {
+			continue
+		}
+		sql, end, _ := ctx.concat(stmt, i)
+		if !isRawSQL(stmt, i, sql) {
+			i = end - 1
+			continue
+		}
+		group := conn[i]
+		if group == "" {
+			sql = ctx.swapPrefix(sql, ctx.Group)
+		} else {
+			sql = ctx.swapPrefix(sql, group)
+		}
+		for _, ref := range sqlTableRefs(sql, stmt[i].Line) {
+			ref.Table = normalizeTable(ref.Table)
+			ref.Connection = group
+			refs = append(refs, ref)
+		}
+		i = end - 1
+	}
+
+	return refs, groups
+}
+
+// isRawSQL reports whether the string starting at stmt[i] (sql, after
+// concatenation) is a SQL statement: it reads like one or is passed to
+// query().
+func isRawSQL(stmt []Token, i int, sql string) bool {
+	return sqlStatementRegex.MatchString(sql) || queryArg(stmt, i)
+}
+
+// queryArg reports whether stmt[i] is the first argument of query() or
+// simple_query().
+func queryArg(stmt []Token, i int) bool {
+	if i < 3 || !stmt[i-1].IsOp("(") || !stmt[i-3].IsOp("->") || stmt[i-2].Kind != TokenIdent {
+		return false
+	}
+	name := strings.ToLower(stmt[i-2].Text)
+	return name == "query" || name == "simple_query"
+}
+
+// qbTableName strips aliases from a Query Builder table argument:
+// "users u" and "users AS u" both become "users".
+func qbTableName(arg string) string {
+	fields := strings.Fields(arg)
+	if len(fields) == 0 {
+		return ""
+	}
+	name := strings.Trim(fields[0], "`")
+	for _, c := range name {
+		if !(c == '_' || c == '.' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')) {
+			return ""
+		}
+	}
+	return name
+}
+
+// sqlTableRefs extracts table references from a raw SQL string.
+func sqlTableRefs(sql string, line int) []TableRef {
+	var refs []TableRef
+
+	for _, m := range sqlTableRegex.FindAllStringSubmatchIndex(sql, -1) {
+		keyword := sql[m[2]:m[3]]
+		if strings.EqualFold(keyword, "update") && sqlDuplicateKeyRegex.MatchString(sql[:m[2]]) {
+			continue
+		}
+		refs = append(refs, TableRef{
+			// m[4]:m[5] = table name
+			Table:     sql[m[4]:m[5]],
+			Operation: sqlOperation(keyword),
+			Source:    SourceRawSQL,
+			Line:      line + strings.Count(sql[:m[4]], "\n"),
+		})
+	}
+
+	return refs
+}
+
+func uniqueTableRefs(refs []TableRef) []TableRef {
+	seen := map[string]bool{}
+	var result []TableRef
+	for _, r := range refs {
+		key := r.Table + "|" + r.Operation + "|" + r.Source + "|" + r.Connection
+		if r.Table == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		result = append(result, r)
+	}
+	return result
+}
@@ -0,0 +1,68 @@
+package analyzer
+
+import (
+	"reflect"
+	"testing"
+)
+
+func tableRefsOf(code string) []string {
+	var got []string
+	for _, r := range ExtractTableRefs(Tokenize("<?php " + code)) {
+		got = append(got, r.Operation+" "+r.Table)
+	}
+	return got
+}
+
+func TestExtractTableRefs(t *testing.T) {
+	tests := []struct {
+		name string
+		code string
+		want []string
+	}{
+		{
+			name: "query builder on $this->db",
+			code: `$this->db->where('id', $id)->update('users', $data);`,
+			want: []string{"UPDATE users"},
+		},
+		{
+			name: "get on the input library",
+			code: `$page = $this->input->get('page');`,
+		},
+		{
+			name: "get on the cache driver",
+			code: `$users = $this->cache->get('user_cache');`,
+		},
+		{
+			name: "input get inside a query builder call",
+			code: `$this->db->where('id', $this->input->get('id'))->get('orders');`,
+			want: []string{"SELECT orders"},
+		},
+		{
+			name: "validation message",
+			code: `$this->form_validation->set_message('required', 'Please update your profile from the settings');`,
+		},
+		{
+			name: "raw SQL in query()",
+			code: `$this->db->query("SELECT * FROM orders o JOIN users u ON u.id = o.user_id");`,
+			want: []string{"SELECT orders", "SELECT users"},
+		},
+		{
+			name: "raw SQL built before query()",
+			code: `$sql = "DELETE FROM sessions WHERE expires < ?"; $this->db->query($sql, array(time()));`,
+			want: []string{"DELETE sessions"},
+		},
+		{
+			name: "DDL passed to query()",
+			code: `$this->db->query('TRUNCATE logs');`,
+			want: []string{"TRUNCATE logs"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tableRefsOf(tt.code); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
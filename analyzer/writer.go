@@ -0,0 +1,120 @@
+/*
+Copyright © 2025 Vicky Chhetri <vickychhetri4@gmail.com>
+
+Report writer: writes the rows of a report to SQLite from a single
+goroutine, in one transaction with prepared statements, while the rest of
+the run is analyzed.
+*/
+
+package analyzer
+
+import (
+	"database/sql"
+)
+
+// writeOp is one statement to run with its arguments.
+type writeOp struct {
+	query string
+	args  []any
+}
+
+// sqlReportWriter writes the rows of one report to SQLite. Rows are queued
+// to a single goroutine that runs them with statements prepared once, in
+// a transaction committed by Close: a report holds all of its rows or
+// none. The first error stops the writes; Close returns it.
+type sqlReportWriter struct {
+	db       *sql.DB
+	reportID int64
+	ops      chan writeOp
+	done     chan struct{}
+	err      error
+}
+
+// newReportWriter starts a writer for a report stored in db.
+func newReportWriter(db *sql.DB, reportID int64) ReportWriter {
+	w := &sqlReportWriter{
+		db:       db,
+		reportID: reportID,
+		ops:      make(chan writeOp, 256),
+		done:     make(chan struct{}),
+	}
+	go w.run()
+	return w
+}
+
+func (w *sqlReportWriter) run() {
+	defer close(w.done)
+
+	tx, err := w.db.Begin()
+	if err != nil {
+		w.err = err
+		for range w.ops {
+			// drain
+		}
+		return
+	}
+	// statements prepared on the transaction are closed with it
+	defer tx.Rollback()
+
+	stmts := map[string]*sql.Stmt{}
+	for op := range w.ops {
+		if w.err != nil {
+			continue // drain
+		}
+
+		stmt, ok := stmts[op.query]
+		if !ok {
+			if stmt, w.err = tx.Prepare(op.query); w.err != nil {
+				continue
+			}
+			stmts[op.query] = stmt
+		}
+		_, w.err = stmt.Exec(op.args...)
+	}
+
+	if w.err == nil {
+		w.err = tx.Commit()
+	}
+}
+
+func (w *sqlReportWriter) write(ops ...writeOp) {
+	for _, op := range ops {
+		w.ops <- op
+	}
+}
+
+func (w *sqlReportWriter) Mapping(m Mapping) {
+	w.write(writeOp{insertMappingSQL, mappingArgs(w.reportID, m)})
+}
+
+func (w *sqlReportWriter) UnresolvedLoad(u UnresolvedLoad) {
+	w.write(writeOp{insertUnresolvedLoadSQL, unresolvedLoadArgs(w.reportID, u)})
+}
+
+func (w *sqlReportWriter) ColumnMapping(c ColumnMapping) {
+	w.write(writeOp{insertColumnMappingSQL, columnMappingArgs(w.reportID, c)})
+}
+
+func (w *sqlReportWriter) Route(route Route) {
+	w.write(writeOp{insertRouteSQL, routeArgs(w.reportID, route)})
+}
+
+func (w *sqlReportWriter) Lineage(row LineageRow) {
+	w.write(writeOp{insertLineageSQL, lineageArgs(w.reportID, row)})
+}
+
+func (w *sqlReportWriter) Schema(schema *Schema) {
+	w.write(schemaOps(w.reportID, schema)...)
+}
+
+func (w *sqlReportWriter) SchemaCheck(check SchemaCheck) {
+	w.write(schemaCheckOps(w.reportID, check)...)
+}
+
+// Close waits for the queued rows, commits them and returns the first
+// error.
+func (w *sqlReportWriter) Close() error {
+	close(w.ops)
+	<-w.done
+	return w.err
+}
@@ -0,0 +1,109 @@
+/*
+Copyright © 2025 Vicky Chhetri
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/vickychhetri/ci3-analyzer/analyzer"
+)
+
+var crudReportID int64
+var crudFormat string
+var crudOutput string
+
+var crudCmd = &cobra.Command{
+	Use:   "crud",
+	Short: "Modules × tables CRUD matrix",
+	Long:  "Build a modules × tables CRUD matrix from the controller-model-table mapping stored by the map command",
+	Run: func(cmd *cobra.Command, args []string) {
+
+		store, err := openStore()
+		if err != nil {
+			fmt.Println("DB error:", err)
+			return
+		}
+		defer store.Close()
+
+		reportID := crudReportID
+		if reportID == 0 && projectPath != "" {
+			reportID, err = store.LatestProjectReportID("map", projectPath)
+			if err != nil {
+				fmt.Println("error:", err)
+				os.Exit(1)
+			}
+		}
+		if reportID == 0 {
+			reportID, err = store.LatestReportID("map")
+			if err != nil {
+				fmt.Println("error:", err)
+				os.Exit(1)
+			}
+		}
+
+		mappings, err := store.LoadMappings(reportID)
+		if err != nil {
+			fmt.Println("Failed to load mapping:", err)
+			os.Exit(1)
+		}
+
+		matrix := analyzer.BuildCRUDMatrix(reportID, mappings)
+
+		switch strings.ToLower(crudFormat) {
+		case "html", "":
+			output := crudOutput
+			if output == "" {
+				output = "ci3-crud.html"
+			}
+			if err := analyzer.GenerateCRUDHTMLReport(output, matrix); err != nil {
+				fmt.Println("HTML Generation Failed: ", err)
+				os.Exit(1)
+			}
+			fmt.Println("CRUD Matrix Generated: ", output)
+
+		case "csv", "json":
+			out := os.Stdout
+			if crudOutput != "" {
+				f, err := os.Create(crudOutput)
+				if err != nil {
+					fmt.Println("error:", err)
+					os.Exit(1)
+				}
+				defer f.Close()
+				out = f
+			}
+
+			if strings.ToLower(crudFormat) == "csv" {
+				err = matrix.WriteCSV(out)
+			} else {
+				err = matrix.WriteJSON(out)
+			}
+			if err != nil {
+				fmt.Println("error:", err)
+				os.Exit(1)
+			}
+
+		default:
+			fmt.Printf("unknown format %q (use html, csv or json)\n", crudFormat)
+			os.Exit(1)
+		}
+
+		if len(matrix.SharedWrites) > 0 {
+			fmt.Fprintln(os.Stderr, "Tables written by more than one module:", strings.Join(matrix.SharedWrites, ", "))
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(crudCmd)
+
+	crudCmd.Flags().StringVarP(&projectPath, "project", "p", "", "Path to CI3 project: its latest map report (default: the latest map report of any project)")
+	crudCmd.Flags().Int64VarP(&crudReportID, "report", "r", 0, "Map report ID (default: latest map report)")
+	crudCmd.Flags().StringVarP(&crudFormat, "format", "f", "html", "Output format: html, csv or json")
+	crudCmd.Flags().StringVarP(&crudOutput, "output", "o", "", "Output file (default: ci3-crud.html for html, stdout otherwise)")
+}
@@ -0,0 +1,158 @@
+/*
+Copyright © 2025 Vicky Chhetri
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/spf13/cobra"
+	"github.com/vickychhetri/ci3-analyzer/analyzer"
+)
+
+var dbCmd = &cobra.Command{
+	Use:   "db",
+	Short: "Manage ci3-analyzer.db",
+	Long:  "Inspect and upgrade the SQLite database the analyzer stores its reports in",
+}
+
+var dbMigrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "Upgrade ci3-analyzer.db to the latest schema version",
+	Run: func(cmd *cobra.Command, args []string) {
+
+		path, err := resolveDBPath()
+		if err != nil {
+			fmt.Println("DB error:", err)
+			os.Exit(1)
+		}
+		if _, err := os.Stat(path); err != nil {
+			fmt.Println("DB error:", err)
+			os.Exit(1)
+		}
+
+		db, err := analyzer.ConnectDB(path)
+		if err != nil {
+			fmt.Println("DB error:", err)
+			os.Exit(1)
+		}
+		defer db.Close()
+
+		fmt.Println("Database:", path)
+
+		from, err := analyzer.DBVersion(db)
+		if err != nil {
+			fmt.Println("DB error:", err)
+			os.Exit(1)
+		}
+
+		applied, err := analyzer.MigrateDB(db)
+		for _, m := range applied {
+			fmt.Printf("Applied %d: %s\n", m.Version, m.Name)
+		}
+		if err != nil {
+			fmt.Println("Migration failed:", err)
+			os.Exit(1)
+		}
+
+		if len(applied) == 0 {
+			fmt.Printf("Schema is up to date (version %d)\n", from)
+			return
+		}
+		fmt.Printf("Schema upgraded from version %d to %d\n", from, analyzer.LatestDBVersion())
+	},
+}
+
+var dbInfoCmd = &cobra.Command{
+	Use:   "info",
+	Short: "Show the schema version and contents of ci3-analyzer.db",
+	Run: func(cmd *cobra.Command, args []string) {
+
+		path, err := resolveDBPath()
+		if err != nil {
+			fmt.Println("DB error:", err)
+			os.Exit(1)
+		}
+		if _, err := os.Stat(path); err != nil {
+			fmt.Println("DB error:", err)
+			os.Exit(1)
+		}
+
+		db, err := analyzer.ConnectDB(path)
+		if err != nil {
+			fmt.Println("DB error:", err)
+			os.Exit(1)
+		}
+		defer db.Close()
+
+		fmt.Println("Database:", path)
+
+		version, err := analyzer.DBVersion(db)
+		if err != nil {
+			fmt.Println("DB error:", err)
+			os.Exit(1)
+		}
+
+		fmt.Printf("Schema version: %d (latest %d)\n", version, analyzer.LatestDBVersion())
+
+		applied, err := analyzer.AppliedDBMigrations(db)
+		if err != nil {
+			fmt.Println("DB error:", err)
+			os.Exit(1)
+		}
+		for _, m := range applied {
+			fmt.Printf(" - %d: %s (%s)\n", m.Version, m.Name, m.AppliedAt)
+		}
+
+		pending, err := analyzer.PendingDBMigrations(db)
+		if err != nil {
+			fmt.Println("DB error:", err)
+			os.Exit(1)
+		}
+		if len(pending) > 0 {
+			fmt.Println("Pending migrations (run `ci3-analyzer db migrate`):")
+			for _, m := range pending {
+				fmt.Printf(" - %d: %s\n", m.Version, m.Name)
+			}
+		}
+
+		counts, err := analyzer.DBTableCounts(db)
+		if err != nil {
+			fmt.Println("DB error:", err)
+			os.Exit(1)
+		}
+		var tables []string
+		for t := range counts {
+			tables = append(tables, t)
+		}
+		sort.Strings(tables)
+
+		fmt.Println("Tables:")
+		for _, t := range tables {
+			fmt.Printf(" - %s: %d rows\n", t, counts[t])
+		}
+
+		if version < analyzer.LatestDBVersion() {
+			return
+		}
+		projects, err := analyzer.NewSQLiteStore(db).ListProjects()
+		if err != nil {
+			fmt.Println("DB error:", err)
+			os.Exit(1)
+		}
+		fmt.Println("Projects:")
+		for _, p := range projects {
+			fmt.Printf(" - %s (%s): %d reports\n", p.Name, p.RootPath, p.Reports)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(dbCmd)
+	dbCmd.PersistentFlags().StringVarP(&projectPath, "project", "p", "", "Path to CI3 project (for --project-store)")
+	dbCmd.AddCommand(dbMigrateCmd)
+	dbCmd.AddCommand(dbInfoCmd)
+}
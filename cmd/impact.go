@@ -0,0 +1,153 @@
+/*
+Copyright © 2025 Vicky Chhetri
+*/
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/vickychhetri/ci3-analyzer/analyzer"
+)
+
+var impactReportID int64
+var impactTable string
+var impactColumn string
+var impactFormat string
+
+var impactCmd = &cobra.Command{
+	Use:   "impact",
+	Short: "What code depends on a table or column",
+	Long:  "List the models, controllers, controller methods and URLs that use a table (or one of its columns), grouped by module and operation, from the data stored by the map command",
+	Run: func(cmd *cobra.Command, args []string) {
+
+		if impactTable == "" {
+			fmt.Println("--table is required")
+			os.Exit(1)
+		}
+
+		store, err := openStore()
+		if err != nil {
+			fmt.Println("DB error:", err)
+			return
+		}
+		defer store.Close()
+
+		reportID := impactReportID
+		if reportID == 0 && projectPath != "" {
+			reportID, err = store.LatestProjectReportID("map", projectPath)
+			if err != nil {
+				fmt.Println("error:", err)
+				os.Exit(1)
+			}
+		}
+		if reportID == 0 {
+			reportID, err = store.LatestReportID("map")
+			if err != nil {
+				fmt.Println("error:", err)
+				os.Exit(1)
+			}
+		}
+
+		mappings, err := store.LoadMappings(reportID)
+		if err != nil {
+			fmt.Println("Failed to load mapping:", err)
+			os.Exit(1)
+		}
+		lineage, err := store.LoadLineage(reportID)
+		if err != nil {
+			fmt.Println("Failed to load lineage:", err)
+			os.Exit(1)
+		}
+		var columns []analyzer.ColumnMapping
+		if impactColumn != "" {
+			columns, err = store.LoadColumnMappings(reportID)
+			if err != nil {
+				fmt.Println("Failed to load column mapping:", err)
+				os.Exit(1)
+			}
+		}
+
+		// "users" also matches ci_users when the project uses a dbprefix
+		path := projectPath
+		if path == "" {
+			path, _ = store.ReportProjectPath(reportID)
+		}
+		var prefixes []string
+		if dbc, err := analyzer.ParseDatabaseConfig(path); err == nil {
+			for _, g := range dbc.Groups {
+				prefixes = append(prefixes, g.Prefix)
+			}
+		}
+
+		impact := analyzer.BuildImpact(reportID, impactTable, impactColumn, prefixes, mappings, lineage, columns)
+
+		switch strings.ToLower(impactFormat) {
+		case "json":
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			err = enc.Encode(impact)
+		case "text", "":
+			writeImpactText(impact)
+		default:
+			err = fmt.Errorf("unknown format %q (use text or json)", impactFormat)
+		}
+
+		if err != nil {
+			fmt.Println("error:", err)
+			os.Exit(1)
+		}
+	},
+}
+
+func writeImpactText(impact *analyzer.Impact) {
+	target := impact.Table
+	if impact.Column != "" {
+		target += "." + impact.Column
+	}
+	fmt.Printf("Impact of %s (map report #%d)\n", target, impact.ReportID)
+
+	if len(impact.Groups) == 0 {
+		fmt.Println("Nothing references it.")
+		return
+	}
+	if len(impact.Tables) > 0 {
+		fmt.Println("Tables:", strings.Join(impact.Tables, ", "))
+	}
+
+	for _, g := range impact.Groups {
+		module := g.Module
+		if module == "" {
+			module = analyzer.AppModule
+		}
+		fmt.Printf("\n[%s] %s\n", module, g.Operation)
+		printImpactList("Models", g.Models)
+		printImpactList("Controllers", g.Controllers)
+		printImpactList("Methods", g.Methods)
+		printImpactList("URLs", g.URLs)
+	}
+}
+
+func printImpactList(label string, items []string) {
+	if len(items) == 0 {
+		return
+	}
+	fmt.Printf("  %s:\n", label)
+	for _, item := range items {
+		fmt.Println("    " + item)
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(impactCmd)
+
+	impactCmd.Flags().StringVarP(&projectPath, "project", "p", "", "Path to CI3 project: its latest map report and its dbprefix (default: the latest map report of any project)")
+	impactCmd.Flags().Int64VarP(&impactReportID, "report", "r", 0, "Map report ID (default: latest map report)")
+	impactCmd.Flags().StringVar(&impactTable, "table", "", "Table name, with or without the dbprefix")
+	impactCmd.Flags().StringVar(&impactColumn, "column", "", "Only code using this column of the table")
+	impactCmd.Flags().StringVarP(&impactFormat, "format", "f", "text", "Output format: text or json")
+}
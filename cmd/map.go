@@ -7,13 +7,15 @@ package cmd
 import (
 	"fmt"
 	"os"
-	"path/filepath"
-	"sync"
 
 	"github.com/spf13/cobra"
 	"github.com/vickychhetri/ci3-analyzer/analyzer"
 )
 
+var mapSchema string
+var mapSchemaGroup string
+var mapMigrations bool
+
 var mapCmd = &cobra.Command{
 	Use:   "map",
 	Short: "Map Controller → Model → Tables (CI3 HMVC)",
@@ -25,22 +27,27 @@ var mapCmd = &cobra.Command{
 			os.Exit(1)
 		}
 
+		if mapSchema != "" && mapMigrations {
+			fmt.Println("--schema and --migrations cannot be used together")
+			os.Exit(1)
+		}
+
 		fmt.Println("Mapping Project/:", projectPath)
 
 		// --------------------------------------------------
 		// Open SQLite DB
 		// --------------------------------------------------
-		db, err := analyzer.OpenDB()
+		store, err := openStore()
 		if err != nil {
 			fmt.Println("DB error:", err)
 			return
 		}
-		defer db.Close()
+		defer store.Close()
 
 		// --------------------------------------------------
 		// Create new report entry
 		// --------------------------------------------------
-		reportID, err := analyzer.CreateReport(db, "map", projectPath)
+		reportID, err := store.CreateReport("map", projectPath)
 		if err != nil {
 			fmt.Println("Failed to create report:", err)
 			return
@@ -48,77 +55,153 @@ var mapCmd = &cobra.Command{
 
 		fmt.Println("Mapping Report ID:", reportID)
 
+		// the report stays running until every row is committed
+		fail := func(msg string, err error) {
+			fmt.Println(msg, err)
+			if err := store.FailReport(reportID, err); err != nil {
+				fmt.Println("Failed to mark report as failed:", err)
+			}
+		}
+
 		// --------------------------------------------------
-		// Scan HMVC modules
+		// Scan HMVC modules and the plain CI3 application
 		// --------------------------------------------------
 		modules, err := analyzer.ScanModules(projectPath)
 		if err != nil {
-			fmt.Println("error:", err)
+			fail("error:", err)
 			return
 		}
 
-		var wg sync.WaitGroup
-		var mu sync.Mutex
+		reports := analyzer.BuildReports(modules)
+		analyzer.ResolveInheritance(projectPath, reports)
+
+		schemaGroup := mapSchemaGroup
+		if dbc, err := analyzer.ParseDatabaseConfig(projectPath); err == nil {
+			if schemaGroup == "" {
+				schemaGroup = dbc.ActiveGroup
+			}
+			for _, g := range dbc.Groups {
+				active := ""
+				if g.Name == dbc.ActiveGroup {
+					active = " (active)"
+				}
+				fmt.Printf("DB group: %s%s driver=%s database=%s prefix=%q\n", g.Name, active, g.Driver, g.Database, g.Prefix)
+			}
+		}
 
-		for _, module := range modules {
-			wg.Add(1)
+		// --------------------------------------------------
+		// Controller method → model method → table
+		// --------------------------------------------------
+		idx := analyzer.BuildCallIndex(projectPath, reports)
+		mappings, unresolved := analyzer.BuildMapping(idx, reports)
 
-			go func(mod string) {
-				defer wg.Done()
+		// --------------------------------------------------
+		// Check the tables against the real schema
+		// --------------------------------------------------
+		var schema *analyzer.Schema
+		switch {
+		case mapSchema != "":
+			schema, err = analyzer.LoadSchemaFile(mapSchema)
+		case mapMigrations:
+			schema, err = analyzer.LoadMigrationSchema(projectPath)
+		}
+		if err != nil {
+			fail("Schema error:", err)
+			return
+		}
 
-				modulePath := filepath.Join(projectPath, "application", "modules", mod)
-				controllersPath := filepath.Join(modulePath, "controllers")
-				modelsPath := filepath.Join(modulePath, "models")
+		// --------------------------------------------------
+		// Write the rows in the background, committed as a whole
+		// --------------------------------------------------
+		w := store.NewWriter(reportID)
+
+		if schema != nil {
+			w.Schema(schema)
+			fmt.Printf("Schema tables: %d (from %s)\n", len(schema.Tables), schema.Source)
+
+			var check analyzer.SchemaCheck
+			mappings, check = schema.Check(mappings, schemaGroup)
+			w.SchemaCheck(check)
+
+			for _, m := range check.Unknown {
+				status := "unknown table"
+				if m.Source == analyzer.SourceRawSQL {
+					status = "discarded"
+				}
+				fmt.Printf("Unknown table %q (%s %s) in %s::%s [%s]\n", m.Table, m.Operation, m.Source, m.Model, m.ModelMethod, status)
+			}
+			for _, t := range check.Unreferenced {
+				fmt.Println("Table never referenced by code:", t)
+			}
+			fmt.Printf("Unknown table references: %d (%d raw SQL discarded), unreferenced tables: %d\n",
+				len(check.Unknown), check.Discarded, len(check.Unreferenced))
+		}
 
-				filepath.Walk(controllersPath, func(path string, info os.FileInfo, err error) error {
-					if err != nil || info.IsDir() || filepath.Ext(path) != ".php" {
-						return nil
-					}
+		for _, m := range mappings {
+			w.Mapping(m)
+		}
 
-					controllerCode, err := os.ReadFile(path)
-					if err != nil {
-						return nil
-					}
+		fmt.Println("Mappings found:", len(mappings))
 
-					controllerName := filepath.Base(path)
-					models := analyzer.ExtractModels(string(controllerCode))
+		for _, u := range unresolved {
+			w.UnresolvedLoad(u)
+			fmt.Printf("Unresolved model load: %s:%d %s (%s)\n", u.File, u.Load.Line, u.Load.Path, u.Reason)
+		}
 
-					for _, model := range models {
-						modelFile := filepath.Join(modelsPath, model+".php")
-						if _, err := os.Stat(modelFile); err != nil {
-							continue
-						}
+		if len(unresolved) > 0 {
+			fmt.Println("Unresolved model loads:", len(unresolved))
+		}
+
+		// --------------------------------------------------
+		// Model method → table.column
+		// --------------------------------------------------
+		columns := analyzer.BuildColumnMapping(idx)
+		if schema != nil {
+			columns = schema.FilterColumns(columns, schemaGroup)
+			for _, c := range schema.CheckColumns(columns, schemaGroup) {
+				fmt.Printf("Unknown column %s.%s (%s %s) in %s::%s\n", c.Table, c.Column, c.Operation, c.Clause, c.Model, c.ModelMethod)
+			}
+		}
 
-						modelCode, err := os.ReadFile(modelFile)
-						if err != nil {
-							continue
-						}
+		for _, c := range columns {
+			w.ColumnMapping(c)
+		}
 
-						tables := analyzer.ExtractTables(string(modelCode))
+		fmt.Println("Column references:", len(columns))
 
-						for _, table := range tables {
-							mu.Lock()
-							_ = analyzer.SaveMapping(
-								db,
-								reportID,
-								mod,
-								controllerName,
-								model,
-								table,
-								path,
-								modelFile,
-							)
-							mu.Unlock()
-						}
-					}
-					return nil
-				})
+		// --------------------------------------------------
+		// Route table (routes.php + implicit controller URLs)
+		// --------------------------------------------------
+		routeTable := analyzer.BuildRouteTable(projectPath, reports)
 
-			}(module)
+		for _, route := range routeTable.Routes {
+			w.Route(route)
 		}
 
-		wg.Wait()
+		fmt.Println("Routes found:", len(routeTable.Routes))
 
+		// --------------------------------------------------
+		// Route → controller method → model method → table
+		// --------------------------------------------------
+		lineage := analyzer.BuildLineage(idx, routeTable, reports)
+		if schema != nil {
+			lineage = schema.FilterLineage(lineage, schemaGroup)
+		}
+
+		for _, row := range lineage {
+			w.Lineage(row)
+		}
+
+		fmt.Println("Lineage rows:", len(lineage))
+
+		if err := w.Close(); err != nil {
+			fail("Failed to save report:", err)
+			return
+		}
+		if err := store.CompleteReport(reportID); err != nil {
+			fmt.Println("Failed to complete report:", err)
+			return
+		}
 		fmt.Println("Mapping completed successfully.")
 	},
 }
@@ -133,4 +216,7 @@ func init() {
 		"",
 		"Path to CI3 project",
 	)
+	mapCmd.Flags().StringVar(&mapSchema, "schema", "", "SQL dump (CREATE TABLE statements) to validate the mapped tables against")
+	mapCmd.Flags().BoolVar(&mapMigrations, "migrations", false, "build the schema from the project's migrations instead of a SQL dump")
+	mapCmd.Flags().StringVar(&mapSchemaGroup, "schema-group", "", "database.php group the schema describes (default: the active group)")
 }
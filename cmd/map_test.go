@@ -0,0 +1,108 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/vickychhetri/ci3-analyzer/analyzer"
+)
+
+// writeProject creates a CI3 application with a users module below dir.
+func writeProject(t *testing.T, dir string) {
+	t.Helper()
+	files := map[string]string{
+		"application/config/config.php": `<?php $config['base_url'] = '';`,
+		"application/controllers/Welcome.php": `<?php
+class Welcome extends CI_Controller {
+    public function index() { $this->load->model('Page_model'); $this->Page_model->all(); }
+}`,
+		"application/models/Page_model.php": `<?php
+class Page_model extends CI_Model {
+    public function all() { return $this->db->get('pages')->result(); }
+}`,
+		"application/modules/users/controllers/Users.php": `<?php
+class Users extends MX_Controller {
+    public function save() { $this->load->model('User_model'); $this->User_model->save(); }
+}`,
+		"application/modules/users/models/User_model.php": `<?php
+class User_model extends CI_Model {
+    public function save() { $this->db->insert('users', array('name' => 'x')); }
+}`,
+	}
+	for name, code := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(code), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// runMap runs the map command against a MemoryStore.
+func runMap(t *testing.T, args ...string) *analyzer.MemoryStore {
+	t.Helper()
+	store := analyzer.NewMemoryStore()
+	orig := newStore
+	newStore = func(string) (analyzer.Store, error) { return store, nil }
+	t.Cleanup(func() {
+		newStore = orig
+		mapSchema, mapSchemaGroup, mapMigrations = "", "", false
+	})
+
+	rootCmd.SetArgs(append([]string{"map", "--db", filepath.Join(t.TempDir(), "unused.db")}, args...))
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	return store
+}
+
+func TestMapCommand(t *testing.T) {
+	dir := t.TempDir()
+	writeProject(t, dir)
+
+	store := runMap(t, "-p", dir)
+
+	id, err := store.LatestProjectReportID("map", dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mappings, err := store.LoadMappings(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := map[string]bool{}
+	for _, m := range mappings {
+		got[m.Module+" "+m.Controller+"::"+m.ControllerMethod+" "+m.Operation+" "+m.Table] = true
+	}
+	for _, want := range []string{
+		analyzer.AppModule + " Welcome.php::index SELECT pages",
+		"users Users.php::save INSERT users",
+	} {
+		if !got[want] {
+			t.Errorf("mapping %q not found in %v", want, got)
+		}
+	}
+
+	lineage, err := store.LoadLineage(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(lineage) == 0 {
+		t.Error("no lineage rows stored")
+	}
+}
+
+func TestMapCommandFailedReport(t *testing.T) {
+	dir := t.TempDir()
+	writeProject(t, dir)
+
+	store := runMap(t, "-p", dir, "--schema", filepath.Join(dir, "missing.sql"))
+
+	if _, err := store.LatestProjectReportID("map", dir); err == nil {
+		t.Error("a map run with a schema error left a complete report")
+	}
+}
@@ -4,11 +4,16 @@ Copyright © 2025 Vicky Chhetri <vickychhetri4@gmail.com>
 package cmd
 
 import (
+	"fmt"
 	"os"
 
 	"github.com/spf13/cobra"
+	"github.com/vickychhetri/ci3-analyzer/analyzer"
 )
 
+var dbPath string
+var projectStore bool
+
 var rootCmd = &cobra.Command{
 	Use:   "ci3-analyzer",
 	Short: "CI3 HMVC code analyzer",
@@ -24,7 +29,43 @@ func Execute() {
 	}
 }
 
+// resolveDBPath returns the database chosen by --db, CI3_ANALYZER_DB, the
+// project store or the per-user data directory.
+func resolveDBPath() (string, error) {
+	path, err := analyzer.ResolveDBPath(dbPath, projectPath, projectStore)
+	if err == nil && path == analyzer.DBFileName && dbPath == "" && os.Getenv(analyzer.DBPathEnv) == "" {
+		if def, err := analyzer.DefaultDBPath(); err == nil {
+			fmt.Fprintf(os.Stderr, "Using ./%s from an older version; move it to %s (or pass --db) to keep using it from any directory\n", analyzer.DBFileName, def)
+		}
+	}
+	return path, err
+}
+
+// newStore opens the store at a database path; tests replace it with a
+// MemoryStore.
+var newStore = func(path string) (analyzer.Store, error) {
+	return analyzer.OpenSQLiteStore(path)
+}
+
+// openStore opens (and upgrades) the database of the current command.
+func openStore() (analyzer.Store, error) {
+	path, err := resolveDBPath()
+	if err != nil {
+		return nil, err
+	}
+	store, err := newStore(path)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return store, nil
+}
+
 func init() {
 
 	rootCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
+
+	rootCmd.PersistentFlags().StringVar(&dbPath, "db", "",
+		"SQLite database file (default: $"+analyzer.DBPathEnv+", the project store, or ci3-analyzer/"+analyzer.DBFileName+" in the user data directory)")
+	rootCmd.PersistentFlags().BoolVar(&projectStore, "project-store", false,
+		"Keep the database inside the project ("+analyzer.ProjectStoreDir+"/"+analyzer.DBFileName+")")
 }
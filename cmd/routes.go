@@ -0,0 +1,161 @@
+/*
+Copyright © 2025 Vicky Chhetri
+*/
+
+package cmd
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/vickychhetri/ci3-analyzer/analyzer"
+)
+
+var routesModule string
+var routesFormat string
+var routesURL string
+var routesVerb string
+
+var routesCmd = &cobra.Command{
+	Use:   "routes",
+	Short: "List every reachable URL of a CI3 project",
+	Long:  "Resolve routes.php (application and HMVC modules) and the implicit /module/controller/method URLs to controller methods",
+	Run: func(cmd *cobra.Command, args []string) {
+
+		if projectPath == "" {
+			fmt.Println("Project path is required")
+			os.Exit(1)
+		}
+
+		modules, err := analyzer.ScanModules(projectPath)
+		if err != nil {
+			fmt.Println("error:", err)
+			return
+		}
+
+		reports := analyzer.BuildReports(modules)
+		analyzer.ResolveInheritance(projectPath, reports)
+		table := analyzer.BuildRouteTable(projectPath, reports)
+
+		var routes []analyzer.Route
+
+		if routesURL != "" {
+			route, ok := table.Resolve(routesURL, routesVerb)
+			if !ok {
+				fmt.Println("No controller method serves", routesURL)
+				os.Exit(1)
+			}
+			routes = append(routes, route)
+		} else {
+			for _, r := range table.Routes {
+				if routesModule != "" && !strings.EqualFold(r.Module, routesModule) {
+					continue
+				}
+				routes = append(routes, r)
+			}
+		}
+
+		switch strings.ToLower(routesFormat) {
+		case "json":
+			err = writeRoutesJSON(routes)
+		case "csv":
+			err = writeRoutesCSV(routes)
+		case "table", "":
+			err = writeRoutesTable(routes)
+		default:
+			err = fmt.Errorf("unknown format %q (use table, json or csv)", routesFormat)
+		}
+
+		if err != nil {
+			fmt.Println("error:", err)
+			os.Exit(1)
+		}
+	},
+}
+
+func routeKind(r analyzer.Route) string {
+	if r.Explicit {
+		return "explicit"
+	}
+	return "implicit"
+}
+
+func routeVerb(r analyzer.Route) string {
+	if r.Verb == "" {
+		return "ANY"
+	}
+	return r.Verb
+}
+
+func writeRoutesTable(routes []analyzer.Route) error {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "URL\tVERB\tMODULE\tCLASS\tMETHOD\tTYPE\tFILE")
+
+	for _, r := range routes {
+		class, method, file := r.Class, r.Method, r.File
+		if !r.Resolved() {
+			class, method, file = "?", "?", "unresolved: "+r.Target
+		}
+		fmt.Fprintf(w, "/%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			strings.TrimPrefix(r.Pattern, "/"),
+			routeVerb(r),
+			r.Module,
+			class,
+			method,
+			routeKind(r),
+			file,
+		)
+	}
+
+	return w.Flush()
+}
+
+func writeRoutesJSON(routes []analyzer.Route) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(routes)
+}
+
+func writeRoutesCSV(routes []analyzer.Route) error {
+	w := csv.NewWriter(os.Stdout)
+	w.Write([]string{"url", "verb", "module", "controller", "class", "method", "type", "target", "controller_file", "source"})
+
+	for _, r := range routes {
+		w.Write([]string{
+			r.Pattern,
+			routeVerb(r),
+			r.Module,
+			r.Controller,
+			r.Class,
+			r.Method,
+			routeKind(r),
+			r.Target,
+			r.File,
+			r.Source,
+		})
+	}
+
+	w.Flush()
+	return w.Error()
+}
+
+func init() {
+	rootCmd.AddCommand(routesCmd)
+
+	routesCmd.Flags().StringVarP(
+		&projectPath,
+		"project",
+		"p",
+		"",
+		"Path to CI3 project",
+	)
+	routesCmd.Flags().StringVarP(&routesModule, "module", "m", "", "Only list routes of this module")
+	routesCmd.Flags().StringVarP(&routesFormat, "format", "f", "table", "Output format: table, json or csv")
+	routesCmd.Flags().StringVar(&routesURL, "url", "", "Show which controller method serves this URL")
+	routesCmd.Flags().StringVar(&routesVerb, "verb", "GET", "HTTP verb used with --url")
+}
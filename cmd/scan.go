@@ -7,7 +7,7 @@ package cmd
 import (
 	"fmt"
 	"os"
-	"path/filepath"
+	"strings"
 	"sync"
 
 	"github.com/spf13/cobra"
@@ -16,12 +16,14 @@ import (
 
 var projectPath string
 var outputHTML bool
+var outputJSON bool
+var baseClass string
 
 // scanCmd represents the scan command
 var scanCmd = &cobra.Command{
 	Use:   "scan",
-	Short: "Scan CI3 HMVC project",
-	Long:  "Scan CodeIgniter 3 HMVC project and extract modules, classes, and methods",
+	Short: "Scan CI3 project",
+	Long:  "Scan CodeIgniter 3 project (plain or HMVC) and extract modules, classes, and methods",
 	Run: func(cmd *cobra.Command, args []string) {
 		if projectPath == "" {
 			fmt.Println("Project path is required")
@@ -45,12 +47,11 @@ var scanCmd = &cobra.Command{
 
 		for _, m := range modules {
 			w.Add(1)
-			go func(module string) {
+			go func(module analyzer.Module) {
 				defer w.Done()
-				fmt.Println(" -", module)
-				modulePath := filepath.Join(projectPath, "application", "modules", module)
+				fmt.Printf(" - %s (%s)\n", module.Name, module.Location)
 
-				report, err := analyzer.BuildReport(module, modulePath)
+				report, err := analyzer.BuildReport(module)
 				if err != nil {
 					fmt.Println("error : ", err)
 					return
@@ -71,9 +72,27 @@ var scanCmd = &cobra.Command{
 			reports = append(reports, *rep)
 			m.Unlock()
 
-			fmt.Println("Module: ", m)
+			fmt.Println("Module: ", rep.Module)
 			for _, f := range rep.Files {
-				fmt.Printf(" - %s, (%d methods)\n", f.ClassName, len(f.Methods))
+				name := strings.Join(f.ClassNames(), ", ")
+				if name == "" {
+					name = f.File
+				}
+				fmt.Printf(" - %s, (%d methods)\n", name, f.MethodCount())
+			}
+		}
+
+		analyzer.ResolveInheritance(projectPath, reports)
+
+		if baseClass != "" {
+			missing := analyzer.ControllersNotDerivingFrom(reports, baseClass)
+			fmt.Printf("Controllers not deriving from %s: %d\n", baseClass, len(missing))
+			for _, c := range missing {
+				chain := "(none)"
+				if len(c.Ancestors) > 0 {
+					chain = strings.Join(c.Ancestors, " → ")
+				}
+				fmt.Printf(" - %s / %s extends %s\n", c.Module, c.ClassName, chain)
 			}
 		}
 
@@ -84,12 +103,55 @@ var scanCmd = &cobra.Command{
 				return
 			}
 
-			fmt.Println("HTML Report Generated:  ci3-reprot.html")
+			fmt.Println("HTML Report Generated:  ci3-reports.html")
+		}
+
+		if outputJSON {
+			err := analyzer.GenerateJSONReport("ci3-reports.json", reports)
+			if err != nil {
+				fmt.Println("JSON Generation Failed: ", err)
+				return
+			}
+
+			fmt.Println("JSON Report Generated:  ci3-reports.json")
 		}
 
+		// --------------------------------------------------
+		// Store the scan; the reports above do not depend on it
+		// --------------------------------------------------
+		if err := storeScan(reports); err != nil {
+			fmt.Println("Warning: scan not stored:", err)
+		}
 	},
 }
 
+// storeScan saves a scan as a new report of the current store.
+func storeScan(reports []analyzer.ModuleReport) error {
+	store, err := openStore()
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	reportID, err := store.CreateReport("scan", projectPath)
+	if err != nil {
+		return err
+	}
+
+	if err := store.SaveScan(reportID, reports); err != nil {
+		if err := store.FailReport(reportID, err); err != nil {
+			fmt.Println("Failed to mark report as failed:", err)
+		}
+		return err
+	}
+	if err := store.CompleteReport(reportID); err != nil {
+		return err
+	}
+
+	fmt.Println("Scan Report ID:", reportID)
+	return nil
+}
+
 func init() {
 	rootCmd.AddCommand(scanCmd)
 	scanCmd.Flags().StringVarP(
@@ -101,5 +163,7 @@ func init() {
 	)
 
 	scanCmd.Flags().BoolVar(&outputHTML, "html", false, "Generate HTML report")
+	scanCmd.Flags().BoolVar(&outputJSON, "json", false, "Generate JSON report")
+	scanCmd.Flags().StringVar(&baseClass, "base", "", "List controllers that do not derive from this base class (e.g. Admin_Controller)")
 
 }
@@ -3,7 +3,7 @@ module github.com/vickychhetri/ci3-analyzer
 go 1.24.0
 
 require (
-	github.com/mattn/go-sqlite3 v1.14.32
+	github.com/spf13/cobra v1.10.2
 	modernc.org/sqlite v1.40.1
 )
 
@@ -14,7 +14,6 @@ require (
 	github.com/mattn/go-isatty v0.0.20 // indirect
 	github.com/ncruces/go-strftime v0.1.9 // indirect
 	github.com/remyoudompheng/bigfft v0.0.0-20230129092748-24d4a6f8daec // indirect
-	github.com/spf13/cobra v1.10.2 // indirect
 	github.com/spf13/pflag v1.0.9 // indirect
 	golang.org/x/exp v0.0.0-20250620022241-b7579e27df2b // indirect
 	golang.org/x/sys v0.36.0 // indirect